@@ -0,0 +1,171 @@
+package hw04lrucache
+
+const (
+	queueT1 uint8 = iota
+	queueT2
+)
+
+// arcPolicy implements Adaptive Replacement Cache (Megiddo and Modha, 2003).
+// t1 holds items seen once recently and t2 items seen at least twice; b1 and
+// b2 remember keys evicted from them. A hit in a ghost list moves the target
+// size p of t1 towards the list that would have kept the item.
+type arcPolicy[K comparable, V any] struct {
+	capacity int
+	p        int
+	t1       GenericList[*cacheItem[K, V]]
+	t2       GenericList[*cacheItem[K, V]]
+	b1       ghostQueue[K]
+	b2       ghostQueue[K]
+
+	// State of the item being admitted, used by evict and add.
+	fromB2    bool
+	frequent  bool
+	discardT1 bool
+}
+
+func NewARCCache(capacity int, opts ...Option) Cache {
+	return NewGenericARCCache[Key, interface{}](capacity, opts...)
+}
+
+func NewGenericARCCache[K comparable, V any](capacity int, opts ...Option) GenericCache[K, V] {
+	return newCache[K, V](capacity, newARCPolicy[K, V](), opts)
+}
+
+func newARCPolicy[K comparable, V any]() *arcPolicy[K, V] {
+	return &arcPolicy[K, V]{
+		t1: NewGenericList[*cacheItem[K, V]](),
+		t2: NewGenericList[*cacheItem[K, V]](),
+		b1: newGhostQueue[K](),
+		b2: newGhostQueue[K](),
+	}
+}
+
+func (p *arcPolicy[K, V]) admit(key K) {
+	c := p.limit()
+	p.fromB2, p.frequent, p.discardT1 = false, false, false
+
+	switch {
+	case p.b1.remove(key):
+		p.p = min(c, p.p+max(p.b2.len()/max(p.b1.len(), 1), 1))
+		p.frequent = true
+	case p.b2.remove(key):
+		p.p = max(0, p.p-max(p.b1.len()/max(p.b2.len(), 1), 1))
+		p.frequent = true
+		p.fromB2 = true
+	case p.t1.Len()+p.b1.len() >= c:
+		if p.b1.len() > 0 {
+			p.b1.removeOldest()
+		} else {
+			p.discardT1 = true
+		}
+	case p.t1.Len()+p.t2.Len()+p.b1.len()+p.b2.len() >= 2*c && p.b2.len() > 0:
+		p.b2.removeOldest()
+	}
+}
+
+func (p *arcPolicy[K, V]) add(item *cacheItem[K, V]) {
+	if p.frequent {
+		item.queue = queueT2
+		item.node = p.t2.PushFront(item)
+	} else {
+		item.queue = queueT1
+		item.node = p.t1.PushFront(item)
+	}
+	p.fromB2, p.frequent, p.discardT1 = false, false, false
+}
+
+func (p *arcPolicy[K, V]) access(item *cacheItem[K, V]) {
+	if item.queue == queueT2 {
+		p.t2.MoveToFront(item.node)
+		return
+	}
+	p.t1.Remove(item.node)
+	item.queue = queueT2
+	item.node = p.t2.PushFront(item)
+}
+
+func (p *arcPolicy[K, V]) remove(item *cacheItem[K, V]) {
+	p.list(item.queue).Remove(item.node)
+}
+
+// evict is the REPLACE routine of the paper.
+func (p *arcPolicy[K, V]) evict() *cacheItem[K, V] {
+	t1 := p.t1.Len()
+	if p.discardT1 && t1 > 0 {
+		p.discardT1 = false
+		item := p.t1.Back().Value
+		p.t1.Remove(item.node)
+		return item
+	}
+
+	if t1 > 0 && (t1 > p.p || (p.fromB2 && t1 == p.p) || p.t2.Len() == 0) {
+		item := p.t1.Back().Value
+		p.t1.Remove(item.node)
+		p.b1.push(item.key)
+		p.trimGhosts()
+		return item
+	}
+	item := p.t2.Back().Value
+	p.t2.Remove(item.node)
+	p.b2.push(item.key)
+	p.trimGhosts()
+	return item
+}
+
+func (p *arcPolicy[K, V]) first() *cacheItem[K, V] {
+	if item := value(p.t1.Back()); item != nil {
+		return item
+	}
+	return value(p.t2.Back())
+}
+
+func (p *arcPolicy[K, V]) next(item *cacheItem[K, V]) *cacheItem[K, V] {
+	if item.node.Prev != nil {
+		return item.node.Prev.Value
+	}
+	if item.queue == queueT1 {
+		return value(p.t2.Back())
+	}
+	return nil
+}
+
+func (p *arcPolicy[K, V]) len() int {
+	return p.t1.Len() + p.t2.Len()
+}
+
+func (p *arcPolicy[K, V]) resize(capacity int) {
+	p.capacity = capacity
+	p.p = min(p.p, p.limit())
+	p.trimGhosts()
+}
+
+func (p *arcPolicy[K, V]) clear() {
+	p.t1 = NewGenericList[*cacheItem[K, V]]()
+	p.t2 = NewGenericList[*cacheItem[K, V]]()
+	p.b1 = newGhostQueue[K]()
+	p.b2 = newGhostQueue[K]()
+	p.p = 0
+}
+
+// limit is the size c of the paper. Without an item capacity (cost-bounded
+// caches) the current number of resident items is used instead.
+func (p *arcPolicy[K, V]) limit() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return max(p.len(), 1)
+}
+
+// trimGhosts keeps |t1|+|b1| <= c and the directory size within 2c.
+func (p *arcPolicy[K, V]) trimGhosts() {
+	c := p.limit()
+	p.b1.trim(c - p.t1.Len())
+	p.b2.trim(2*c - p.t1.Len() - p.t2.Len() - p.b1.len())
+}
+
+func (p *arcPolicy[K, V]) list(queue uint8) GenericList[*cacheItem[K, V]] {
+	if queue == queueT2 {
+		return p.t2
+	}
+	return p.t1
+}
@@ -1,80 +1,486 @@
 package hw04lrucache
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrTooLarge     = errors.New("entry cost exceeds the cache budget")
+	ErrNegativeCost = errors.New("entry cost is negative")
+)
+
+// janitorBatch limits how many entries the janitor inspects per mutex acquisition.
+const janitorBatch = 64
 
 type Key string
 
-type Cache interface {
-	Set(key Key, value interface{}) bool
-	Get(key Key) (interface{}, bool)
+type Cache = GenericCache[Key, interface{}]
+
+type GenericCache[K comparable, V any] interface {
+	Set(key K, value V) bool
+	SetWithTTL(key K, value V, ttl time.Duration) bool
+	SetWithCost(key K, value V, cost int64) (bool, error)
+	SetWithTTLAndCost(key K, value V, ttl time.Duration, cost int64) (bool, error)
+	Get(key K) (V, bool)
+	GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error)
+	Peek(key K) (V, bool)
+	Contains(key K) bool
+	Delete(key K) bool
+	Save(w io.Writer) error
+	Load(r io.Reader) error
+	Len() int
+	Keys() []K
+	All() iter.Seq2[K, V]
+	Resize(capacity int) int
 	Clear()
+	Close()
+	Stats() Stats
+}
+
+type cacheItem[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt int64         // unix nanoseconds, zero means no expiration
+	ttl       time.Duration // reused when refresh-ahead reloads the entry
+	cost      int64
+
+	// Position of the item in the eviction policy structures.
+	node   *GenericListItem[*cacheItem[K, V]]
+	bucket *GenericListItem[*lfuBucket[K, V]]
+	queue  uint8
+}
+
+// eviction keeps the key and value at the time the entry left the cache,
+// since an updated item is reused for its new value.
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
 }
 
-type cacheItem struct {
-	key   Key
-	value interface{}
+func (c *cacheItem[K, V]) expired(now int64) bool {
+	return c.expiresAt != 0 && now >= c.expiresAt
 }
 
-type lruCache struct {
+// cache keeps the entries, expiration, costs and statistics, and delegates
+// the choice of what to evict to a policy.
+type cache[K comparable, V any] struct {
 	capacity int
-	queue    List
-	items    map[Key]*ListItem
+	maxCost  int64
+	cost     int64
+	ttl      time.Duration
+	now      func() time.Time
+	onEvict  func(K, V, EvictReason)
+	costFn   func(K, V) int64
+	codec    Codec
+	policy   policy[K, V]
+	items    map[K]*cacheItem[K, V]
+	evicted  []eviction[K, V]
+	loads    map[K]*load[V]
+	failures map[K]failure
+	negTTL   time.Duration
+	refresh  time.Duration
 	mutex    sync.Mutex
+	done     chan struct{}
+	stopOnce sync.Once
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewCache(capacity int, opts ...Option) Cache {
+	return NewGenericCache[Key, interface{}](capacity, opts...)
 }
 
-func NewCache(capacity int) Cache {
-	return &lruCache{
+func NewGenericCache[K comparable, V any](capacity int, opts ...Option) GenericCache[K, V] {
+	return newCache[K, V](capacity, newLRUPolicy[K, V](), opts)
+}
+
+func newCache[K comparable, V any](capacity int, p policy[K, V], opts []Option) *cache[K, V] {
+	o := newOptions(opts)
+	p.resize(capacity)
+	c := &cache[K, V]{
 		capacity: capacity,
-		queue:    NewList(),
-		items:    make(map[Key]*ListItem, capacity),
+		maxCost:  o.maxCost,
+		ttl:      o.ttl,
+		now:      o.now,
+		onEvict:  onEvictFunc[K, V](o),
+		costFn:   costFunc[K, V](o),
+		codec:    o.codec,
+		policy:   p,
+		items:    make(map[K]*cacheItem[K, V], max(capacity, 0)),
+		loads:    make(map[K]*load[V]),
+		failures: make(map[K]failure),
+		negTTL:   o.negativeTTL,
+		refresh:  o.refreshAhead,
+		done:     make(chan struct{}),
+	}
+	if o.janitorInterval > 0 {
+		go c.janitor(o.janitorInterval)
+	}
+	return c
+}
+
+func (c *cache[K, V]) Set(key K, value V) bool {
+	return c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL stores the value for ttl; a non-positive ttl means no expiration.
+func (c *cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	wasInCache, _ := c.set(key, value, ttl, c.entryCost(key, value))
+	return wasInCache
+}
+
+// SetWithCost stores the value with an explicit cost. An entry costing more
+// than the whole budget is rejected with ErrTooLarge and any previous value
+// for the key is removed. Set and SetWithTTL drop such entries silently.
+// In a sharded cache the limit is the budget of the key's shard.
+// A negative cost is rejected with ErrNegativeCost and changes nothing.
+func (c *cache[K, V]) SetWithCost(key K, value V, cost int64) (bool, error) {
+	return c.set(key, value, c.ttl, cost)
+}
+
+// SetWithTTLAndCost combines SetWithTTL and SetWithCost.
+func (c *cache[K, V]) SetWithTTLAndCost(key K, value V, ttl time.Duration, cost int64) (bool, error) {
+	return c.set(key, value, ttl, cost)
+}
+
+func (c *cache[K, V]) set(key K, value V, ttl time.Duration, cost int64) (bool, error) {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	return c.store(key, value, ttl, c.expiry(ttl), cost)
+}
+
+func (c *cache[K, V]) store(key K, value V, ttl time.Duration, expiresAt int64, cost int64) (bool, error) {
+	if cost < 0 {
+		return false, ErrNegativeCost
+	}
+	if c.maxCost > 0 && cost > c.maxCost {
+		if item, exists := c.items[key]; exists {
+			c.remove(item, EvictCapacity)
+		}
+		return false, ErrTooLarge
+	}
+
+	if len(c.failures) > 0 {
+		delete(c.failures, key)
+	}
+	if len(c.loads) > 0 {
+		delete(c.loads, key)
 	}
+
+	if item, exists := c.items[key]; exists {
+		wasInCache := !c.expired(item)
+		if !wasInCache {
+			c.evictions.Add(1)
+			c.notify(item, EvictExpired)
+		}
+		c.cost += cost - item.cost
+		item.value = value
+		item.expiresAt = expiresAt
+		item.ttl = ttl
+		item.cost = cost
+		c.policy.access(item)
+		c.evictOverflow()
+		return wasInCache, nil
+	}
+
+	c.policy.admit(key)
+	for c.policy.len() > 0 && c.exceeds(c.policy.len()+1, c.cost+cost) {
+		c.drop(c.policy.evict(), EvictCapacity)
+	}
+
+	item := &cacheItem[K, V]{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+		ttl:       ttl,
+		cost:      cost,
+	}
+	c.policy.add(item)
+	c.items[key] = item
+	c.cost += cost
+
+	c.evictOverflow()
+	return false, nil
 }
 
-func (l *lruCache) Set(key Key, value interface{}) bool {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
+func (c *cache[K, V]) Get(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	if item := c.lookup(key); item != nil {
+		c.policy.access(item)
+		c.hits.Add(1)
+		return item.value, true
+	}
+	c.misses.Add(1)
+	var zero V
+	return zero, false
+}
 
-	if item, exists := l.items[key]; exists {
-		item.Value.(*cacheItem).value = value
-		l.queue.MoveToFront(item)
+// Peek returns the value without promoting it in the policy or touching Stats.
+func (c *cache[K, V]) Peek(key K) (V, bool) {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	if item := c.lookup(key); item != nil {
+		return item.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *cache[K, V]) Contains(key K) bool {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	return c.lookup(key) != nil
+}
+
+func (c *cache[K, V]) Delete(key K) bool {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	delete(c.failures, key)
+	delete(c.loads, key)
+	if item := c.lookup(key); item != nil {
+		c.remove(item, EvictDeleted)
 		return true
 	}
+	return false
+}
+
+// Len returns the number of stored entries, including expired ones
+// that have not been removed yet.
+func (c *cache[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.policy.len()
+}
+
+// Keys returns the keys of live entries from the last to the first candidate
+// for eviction, i.e. from the most to the least recently used for LRU.
+func (c *cache[K, V]) Keys() []K {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	cacheElem := &cacheItem{
-		key:   key,
-		value: value,
+	items := c.snapshot()
+	keys := make([]K, len(items))
+	for i, item := range items {
+		keys[i] = item.key
 	}
+	return keys
+}
 
-	listItem := l.queue.PushFront(cacheElem)
-	l.items[key] = listItem
+// All iterates over a snapshot of live entries in the Keys order.
+// The cache is not locked while the loop body runs.
+func (c *cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.mutex.Lock()
+		items := c.snapshot()
+		c.mutex.Unlock()
 
-	if l.queue.Len() > l.capacity {
-		backItem := l.queue.Back()
-		if backItem != nil {
-			cacheElem := backItem.Value.(*cacheItem)
-			key := cacheElem.key
-			delete(l.items, key)
-			l.queue.Remove(backItem)
+		for _, item := range items {
+			if !yield(item.key, item.value) {
+				return
+			}
 		}
 	}
-	return false
 }
 
-func (l *lruCache) Get(key Key) (interface{}, bool) {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
+// Resize changes the capacity and returns how many entries were evicted to fit it.
+func (c *cache[K, V]) Resize(capacity int) int {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	c.capacity = capacity
+	c.policy.resize(capacity)
+	return c.evictOverflow()
+}
+
+func (c *cache[K, V]) Clear() {
+	c.mutex.Lock()
+	defer c.unlock()
+	if c.onEvict != nil {
+		for _, item := range c.snapshot() {
+			c.notify(item, EvictCleared)
+		}
+	}
+	c.policy.clear()
+	c.items = make(map[K]*cacheItem[K, V], max(c.capacity, 0))
+	c.failures = make(map[K]failure)
+	c.loads = make(map[K]*load[V])
+	c.cost = 0
+}
+
+// Close stops the janitor goroutine, if any. The cache stays usable.
+func (c *cache[K, V]) Close() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *cache[K, V]) Stats() Stats {
+	c.mutex.Lock()
+	size := c.policy.len()
+	c.mutex.Unlock()
+	return newStats(c.hits.Load(), c.misses.Load(), c.evictions.Load(), size)
+}
+
+func (c *cache[K, V]) entryCost(key K, value V) int64 {
+	if c.costFn != nil {
+		return c.costFn(key, value)
+	}
+	return 1
+}
 
-	if item, exists := l.items[key]; exists {
-		l.queue.MoveToFront(item)
-		cacheElem := item.Value.(*cacheItem)
-		return cacheElem.value, true
+// expiry converts a ttl into the expiration time of an item.
+func (c *cache[K, V]) expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
 	}
-	return nil, false
+	return c.now().Add(ttl).UnixNano()
 }
 
-func (l *lruCache) Clear() {
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
-	l.queue = NewList()
-	l.items = make(map[Key]*ListItem, l.capacity)
+// exceeds reports whether n entries of the given total cost do not fit the
+// capacity or budget.
+func (c *cache[K, V]) exceeds(n int, cost int64) bool {
+	if c.maxCost > 0 {
+		return cost > c.maxCost || (c.capacity > 0 && n > c.capacity)
+	}
+	return n > c.capacity
+}
+
+// evictOverflow removes entries chosen by the policy until the cache fits.
+func (c *cache[K, V]) evictOverflow() int {
+	evicted := 0
+	for c.policy.len() > 0 && c.exceeds(c.policy.len(), c.cost) {
+		c.drop(c.policy.evict(), EvictCapacity)
+		evicted++
+	}
+	return evicted
+}
+
+// snapshot returns live items from the last to the first candidate for eviction.
+func (c *cache[K, V]) snapshot() []*cacheItem[K, V] {
+	now := c.now().UnixNano()
+	items := make([]*cacheItem[K, V], 0, c.policy.len())
+	for item := c.policy.first(); item != nil; item = c.policy.next(item) {
+		if !item.expired(now) {
+			items = append(items, item)
+		}
+	}
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items
+}
+
+// lookup returns the live item for key, removing it if it has expired.
+func (c *cache[K, V]) lookup(key K) *cacheItem[K, V] {
+	item, exists := c.items[key]
+	if !exists {
+		return nil
+	}
+	if c.expired(item) {
+		c.remove(item, EvictExpired)
+		return nil
+	}
+	return item
+}
+
+// expired reads the clock only for entries that have an expiration.
+func (c *cache[K, V]) expired(item *cacheItem[K, V]) bool {
+	return item.expiresAt != 0 && item.expired(c.now().UnixNano())
+}
+
+func (c *cache[K, V]) remove(item *cacheItem[K, V], reason EvictReason) {
+	c.policy.remove(item)
+	c.drop(item, reason)
+}
+
+// drop forgets an item that is no longer tracked by the policy.
+func (c *cache[K, V]) drop(item *cacheItem[K, V], reason EvictReason) {
+	delete(c.items, item.key)
+	c.cost -= item.cost
+	if reason == EvictCapacity || reason == EvictExpired {
+		c.evictions.Add(1)
+	}
+	c.notify(item, reason)
+}
+
+// notify queues an OnEvict call to be made by unlock.
+func (c *cache[K, V]) notify(item *cacheItem[K, V], reason EvictReason) {
+	if c.onEvict != nil {
+		c.evicted = append(c.evicted, eviction[K, V]{key: item.key, value: item.value, reason: reason})
+	}
+}
+
+// unlock releases the mutex and then runs the OnEvict calls queued under it.
+func (c *cache[K, V]) unlock() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.mutex.Unlock()
+	for _, e := range evicted {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+}
+
+func (c *cache[K, V]) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.deleteExpired()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// deleteExpired walks the entries in eviction order in batches, releasing the
+// mutex between them so that concurrent Get and Set are not blocked for a full scan.
+func (c *cache[K, V]) deleteExpired() {
+	c.mutex.Lock()
+	batches := c.policy.len()/janitorBatch + 1
+	c.mutex.Unlock()
+
+	var cursor *cacheItem[K, V]
+	for range batches {
+		cursor = c.deleteExpiredBatch(cursor)
+		if cursor == nil {
+			return
+		}
+	}
+}
+
+func (c *cache[K, V]) deleteExpiredBatch(cursor *cacheItem[K, V]) *cacheItem[K, V] {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	item := cursor
+	if item == nil || c.items[item.key] != item {
+		item = c.policy.first()
+	}
+
+	now := c.now().UnixNano()
+	for i := 0; i < janitorBatch && item != nil; i++ {
+		next := c.policy.next(item)
+		if item.expired(now) {
+			c.remove(item, EvictExpired)
+		}
+		item = next
+	}
+	return item
 }
@@ -0,0 +1,162 @@
+package hw04lrucache
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+const benchCapacity = 1000
+
+func benchKeys(n int) []Key {
+	keys := make([]Key, n)
+	for i := range keys {
+		keys[i] = Key(strconv.Itoa(i))
+	}
+	return keys
+}
+
+func BenchmarkCacheSet(b *testing.B) {
+	keys := benchKeys(benchCapacity * 2)
+	c := NewCache(benchCapacity)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		c.Set(keys[i%len(keys)], i)
+	}
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	keys := benchKeys(benchCapacity * 2)
+	c := NewCache(benchCapacity)
+	for i, k := range keys {
+		c.Set(k, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		c.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkGenericCacheSet(b *testing.B) {
+	keys := benchKeys(benchCapacity * 2)
+	c := NewGenericCache[Key, int](benchCapacity)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		c.Set(keys[i%len(keys)], i)
+	}
+}
+
+func BenchmarkGenericCacheGet(b *testing.B) {
+	keys := benchKeys(benchCapacity * 2)
+	c := NewGenericCache[Key, int](benchCapacity)
+	for i, k := range keys {
+		c.Set(k, i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		c.Get(keys[i%len(keys)])
+	}
+}
+
+// BenchmarkCacheParallel compares a single LRU cache with sharded caches under
+// a 90% Get / 10% Set load; run with -cpu to see how throughput scales.
+func BenchmarkCacheParallel(b *testing.B) {
+	keys := benchKeys(benchCapacity * 2)
+	caches := []struct {
+		name  string
+		cache Cache
+	}{
+		{"lru", NewCache(benchCapacity)},
+		{"sharded-4", NewShardedCache(benchCapacity, 4)},
+		{"sharded-16", NewShardedCache(benchCapacity, 16)},
+		{"sharded-64", NewShardedCache(benchCapacity, 64)},
+	}
+
+	for _, tc := range caches {
+		for i, k := range keys {
+			tc.cache.Set(k, i)
+		}
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := keys[i%len(keys)]
+					if i%10 == 0 {
+						tc.cache.Set(key, i)
+					} else {
+						tc.cache.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func zipfTrace(n int, rnd *rand.Rand) []Key {
+	zipf := rand.NewZipf(rnd, 1.1, 1, benchCapacity*10)
+	trace := make([]Key, n)
+	for i := range trace {
+		trace[i] = Key(strconv.FormatUint(zipf.Uint64(), 10))
+	}
+	return trace
+}
+
+// scanTrace interleaves a zipf workload with long scans of keys that are never reused.
+func scanTrace(n int, rnd *rand.Rand) []Key {
+	trace := zipfTrace(n, rnd)
+	for i := 0; i+benchCapacity*2 < n; i += benchCapacity * 10 {
+		for j := range benchCapacity * 2 {
+			trace[i+j] = Key("scan" + strconv.Itoa(i+j))
+		}
+	}
+	return trace
+}
+
+// loopTrace cycles over slightly more keys than fit into the cache.
+func loopTrace(n int, _ *rand.Rand) []Key {
+	keys := benchKeys(benchCapacity + benchCapacity/5)
+	trace := make([]Key, n)
+	for i := range trace {
+		trace[i] = keys[i%len(keys)]
+	}
+	return trace
+}
+
+// BenchmarkPolicyHitRate replays synthetic traces through every eviction
+// policy, setting the key on a miss, and reports the resulting hit rate.
+func BenchmarkPolicyHitRate(b *testing.B) {
+	workloads := []struct {
+		name  string
+		trace func(n int, rnd *rand.Rand) []Key
+	}{
+		{"zipf", zipfTrace},
+		{"zipf+scan", scanTrace},
+		{"loop", loopTrace},
+	}
+
+	for _, w := range workloads {
+		trace := w.trace(benchCapacity*100, rand.New(rand.NewSource(1)))
+		for _, p := range policies {
+			b.Run(w.name+"/"+p.name, func(b *testing.B) {
+				c := p.newCache(benchCapacity)
+				hits := 0
+				b.ResetTimer()
+				for i := range b.N {
+					key := trace[i%len(trace)]
+					if _, ok := c.Get(key); ok {
+						hits++
+					} else {
+						c.Set(key, i)
+					}
+				}
+				b.ReportMetric(float64(hits)/float64(b.N)*100, "hit%")
+			})
+		}
+	}
+}
@@ -3,8 +3,10 @@ package hw04lrucache
 import (
 	"math/rand"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -79,6 +81,438 @@ func TestCache(t *testing.T) {
 	})
 }
 
+func TestGenericCache(t *testing.T) {
+	t.Run("typed values", func(t *testing.T) {
+		c := NewGenericCache[int, string](2)
+
+		wasInCache := c.Set(1, "one")
+		require.False(t, wasInCache)
+		c.Set(2, "two")
+
+		val, ok := c.Get(1)
+		require.True(t, ok)
+		require.Equal(t, "one", val)
+
+		c.Set(3, "three") // [3, 1]
+
+		val, ok = c.Get(2)
+		require.False(t, ok)
+		require.Equal(t, "", val)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		c := NewGenericCache[string, int](3)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Clear()
+
+		_, ok := c.Get("a")
+		require.False(t, ok)
+		_, ok = c.Get("b")
+		require.False(t, ok)
+
+		wasInCache := c.Set("a", 11)
+		require.False(t, wasInCache)
+	})
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCacheTTL(t *testing.T) {
+	t.Run("set with ttl", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(3, withClock(clock.Now))
+		c.SetWithTTL("a", 1, time.Minute)
+		c.Set("b", 2)
+
+		clock.Add(59 * time.Second)
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+
+		clock.Add(time.Second)
+		val, ok = c.Get("a")
+		require.False(t, ok)
+		require.Nil(t, val)
+
+		clock.Add(time.Hour)
+		val, ok = c.Get("b")
+		require.True(t, ok)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("default ttl", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(3, WithTTL(time.Second), withClock(clock.Now))
+		c.Set("a", 1)
+		c.SetWithTTL("b", 2, 0)
+
+		clock.Add(time.Second)
+		_, ok := c.Get("a")
+		require.False(t, ok)
+		_, ok = c.Get("b")
+		require.True(t, ok)
+	})
+
+	t.Run("set over expired entry", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(3, WithTTL(time.Second), withClock(clock.Now))
+		c.Set("a", 1)
+
+		clock.Add(time.Second)
+		wasInCache := c.Set("a", 2)
+		require.False(t, wasInCache)
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("janitor", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewGenericCache[int, int](janitorBatch*3, WithJanitor(time.Millisecond), withClock(clock.Now))
+		defer c.Close()
+		impl := c.(*cache[int, int])
+
+		for i := range janitorBatch * 3 {
+			if i%2 == 0 {
+				c.SetWithTTL(i, i, time.Second)
+			} else {
+				c.Set(i, i)
+			}
+		}
+		clock.Add(time.Second)
+
+		require.Eventually(t, func() bool {
+			impl.mutex.Lock()
+			defer impl.mutex.Unlock()
+			return impl.policy.len() == janitorBatch*3/2
+		}, time.Second, time.Millisecond)
+
+		_, ok := c.Get(1)
+		require.True(t, ok)
+	})
+
+	t.Run("close is idempotent", func(t *testing.T) {
+		c := NewCache(1, WithJanitor(time.Millisecond))
+		c.Close()
+		c.Close()
+
+		c.Set("a", 1)
+		_, ok := c.Get("a")
+		require.True(t, ok)
+	})
+}
+
+type evicted struct {
+	key    Key
+	value  interface{}
+	reason EvictReason
+}
+
+func TestCacheOnEvict(t *testing.T) {
+	t.Run("reasons", func(t *testing.T) {
+		clock := newFakeClock()
+		var got []evicted
+		c := NewCache(2, withClock(clock.Now), WithOnEvict(func(key Key, value interface{}, reason EvictReason) {
+			got = append(got, evicted{key, value, reason})
+		}))
+
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("c", 3) // [c, b]
+		c.SetWithTTL("d", 4, time.Second)
+		clock.Add(time.Second)
+		c.Get("d")
+		c.Clear()
+
+		require.Equal(t, []evicted{
+			{"a", 1, EvictCapacity},
+			{"b", 2, EvictCapacity},
+			{"d", 4, EvictExpired},
+			{"c", 3, EvictCleared},
+		}, got)
+	})
+
+	t.Run("overwritten expired entry reports its old value", func(t *testing.T) {
+		clock := newFakeClock()
+		var got []evicted
+		c := NewCache(2, withClock(clock.Now), WithOnEvict(func(key Key, value interface{}, reason EvictReason) {
+			got = append(got, evicted{key, value, reason})
+		}))
+
+		c.SetWithTTL("a", "old", time.Second)
+		clock.Add(time.Second)
+		require.False(t, c.Set("a", "new"))
+
+		require.Equal(t, []evicted{{"a", "old", EvictExpired}}, got)
+	})
+
+	t.Run("callback may use the cache", func(t *testing.T) {
+		var c Cache
+		c = NewCache(1, WithOnEvict(func(key Key, value interface{}, _ EvictReason) {
+			c.Get(key)
+		}))
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		_, ok := c.Get("b")
+		require.True(t, ok)
+	})
+
+	t.Run("mismatched types", func(t *testing.T) {
+		require.Panics(t, func() {
+			NewCache(1, WithOnEvict(func(string, int, EvictReason) {}))
+		})
+	})
+}
+
+func TestCacheStats(t *testing.T) {
+	c := NewCache(2)
+	require.Equal(t, Stats{}, c.Stats())
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Get("a")
+	c.Get("b")
+	c.Get("c")
+	c.Get("c")
+
+	require.Equal(t, Stats{
+		Hits:      3,
+		Misses:    1,
+		Evictions: 1,
+		Size:      2,
+		HitRatio:  0.75,
+	}, c.Stats())
+
+	c.Clear()
+	require.Equal(t, 0, c.Stats().Size)
+	require.Equal(t, uint64(1), c.Stats().Evictions)
+}
+
+func TestCacheOperations(t *testing.T) {
+	t.Run("peek does not promote", func(t *testing.T) {
+		c := NewCache(2)
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		val, ok := c.Peek("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+
+		c.Set("c", 3)
+		require.False(t, c.Contains("a"))
+		require.Equal(t, Stats{Size: 2, Evictions: 1}, c.Stats())
+
+		val, ok = c.Peek("a")
+		require.False(t, ok)
+		require.Nil(t, val)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var reasons []EvictReason
+		c := NewCache(3, WithOnEvict(func(_ Key, _ interface{}, reason EvictReason) {
+			reasons = append(reasons, reason)
+		}))
+		c.Set("a", 1)
+		c.Set("b", 2)
+
+		require.True(t, c.Delete("a"))
+		require.False(t, c.Delete("a"))
+		require.False(t, c.Contains("a"))
+		require.Equal(t, 1, c.Len())
+		require.Equal(t, []EvictReason{EvictDeleted}, reasons)
+	})
+
+	t.Run("keys and iteration order", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(5, withClock(clock.Now))
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.SetWithTTL("c", 3, time.Second)
+		c.Set("d", 4)
+		c.Get("a") // [a, d, c, b]
+
+		require.Equal(t, []Key{"a", "d", "c", "b"}, c.Keys())
+
+		clock.Add(time.Second)
+		require.Equal(t, []Key{"a", "d", "b"}, c.Keys())
+
+		var keys []Key
+		var values []interface{}
+		for k, v := range c.All() {
+			keys = append(keys, k)
+			values = append(values, v)
+			c.Set("e", 5) // the cache is not locked during iteration
+		}
+		require.Equal(t, []Key{"a", "d", "b"}, keys)
+		require.Equal(t, []interface{}{1, 4, 2}, values)
+
+		keys = keys[:0]
+		for k := range c.All() {
+			keys = append(keys, k)
+			break
+		}
+		require.Equal(t, []Key{"e"}, keys)
+	})
+
+	t.Run("resize", func(t *testing.T) {
+		c := NewCache(4)
+		for i, k := range []Key{"a", "b", "c", "d"} {
+			c.Set(k, i)
+		}
+		c.Get("a") // [a, d, c, b]
+
+		require.Equal(t, 2, c.Resize(2))
+		require.Equal(t, []Key{"a", "d"}, c.Keys())
+
+		require.Equal(t, 0, c.Resize(3))
+		c.Set("e", 5)
+		require.Equal(t, []Key{"e", "a", "d"}, c.Keys())
+
+		require.Equal(t, 3, c.Resize(0))
+		require.Equal(t, 0, c.Len())
+	})
+}
+
+func TestCacheCost(t *testing.T) {
+	t.Run("explicit cost", func(t *testing.T) {
+		c := NewCache(0, WithMaxCost(10))
+
+		for i, k := range []Key{"a", "b", "c"} {
+			wasInCache, err := c.SetWithCost(k, i, 3)
+			require.NoError(t, err)
+			require.False(t, wasInCache)
+		}
+		c.Get("a") // [a, c, b], cost 9
+
+		wasInCache, err := c.SetWithCost("d", 4, 4) // evicts b
+		require.NoError(t, err)
+		require.False(t, wasInCache)
+		require.Equal(t, []Key{"d", "a", "c"}, c.Keys())
+
+		wasInCache, err = c.SetWithCost("a", 0, 7) // evicts c and d
+		require.NoError(t, err)
+		require.True(t, wasInCache)
+		require.Equal(t, []Key{"a"}, c.Keys())
+	})
+
+	t.Run("cost function", func(t *testing.T) {
+		c := NewGenericCache[string, string](0, WithMaxCost(10), WithCost(func(_ string, v string) int64 {
+			return int64(len(v))
+		}))
+		c.Set("a", strings.Repeat("a", 4))
+		c.Set("b", strings.Repeat("b", 4))
+		c.Set("c", strings.Repeat("c", 4))
+
+		require.Equal(t, []string{"c", "b"}, c.Keys())
+		require.Equal(t, uint64(1), c.Stats().Evictions)
+	})
+
+	t.Run("item count limit still applies", func(t *testing.T) {
+		c := NewCache(2, WithMaxCost(100))
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("c", 3)
+
+		require.Equal(t, []Key{"c", "b"}, c.Keys())
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		c := NewCache(0, WithMaxCost(10))
+		_, err := c.SetWithCost("a", 1, 5)
+		require.NoError(t, err)
+		_, err = c.SetWithCost("b", 2, 5)
+		require.NoError(t, err)
+
+		wasInCache, err := c.SetWithCost("a", 11, 11)
+		require.ErrorIs(t, err, ErrTooLarge)
+		require.False(t, wasInCache)
+		require.Equal(t, []Key{"b"}, c.Keys())
+
+		_, err = c.SetWithCost("c", 3, 10)
+		require.NoError(t, err)
+		require.Equal(t, []Key{"c"}, c.Keys())
+	})
+
+	t.Run("ttl and cost", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewCache(0, WithMaxCost(10), withClock(clock.Now))
+		_, err := c.SetWithTTLAndCost("a", 1, time.Second, 6)
+		require.NoError(t, err)
+		_, err = c.SetWithTTLAndCost("b", 2, time.Second, 11)
+		require.ErrorIs(t, err, ErrTooLarge)
+		_, err = c.SetWithTTLAndCost("c", 3, 0, 4)
+		require.NoError(t, err)
+		require.Equal(t, []Key{"c", "a"}, c.Keys())
+
+		clock.Add(time.Second)
+		require.Equal(t, []Key{"c"}, c.Keys())
+	})
+
+	t.Run("negative cost", func(t *testing.T) {
+		c := NewCache(0, WithMaxCost(10))
+		_, err := c.SetWithCost("a", 1, 10)
+		require.NoError(t, err)
+
+		wasInCache, err := c.SetWithCost("a", 2, -5)
+		require.ErrorIs(t, err, ErrNegativeCost)
+		require.False(t, wasInCache)
+		_, err = c.SetWithCost("b", 3, -5)
+		require.ErrorIs(t, err, ErrNegativeCost)
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+		_, err = c.SetWithCost("c", 4, 1)
+		require.NoError(t, err)
+		require.Equal(t, []Key{"c"}, c.Keys())
+
+		c = NewCache(0, WithMaxCost(10), WithCost(func(_ Key, v interface{}) int64 {
+			return int64(v.(int))
+		}))
+		c.Set("a", -5)
+		require.False(t, c.Contains("a"))
+	})
+
+	t.Run("resize and clear release cost", func(t *testing.T) {
+		c := NewCache(3, WithMaxCost(6))
+		c.SetWithCost("a", 1, 2)
+		c.SetWithCost("b", 2, 2)
+		c.SetWithCost("c", 3, 2)
+
+		require.Equal(t, 1, c.Resize(2))
+		c.SetWithCost("d", 4, 2)
+		require.Equal(t, []Key{"d", "c"}, c.Keys())
+
+		c.Clear()
+		_, err := c.SetWithCost("e", 5, 6)
+		require.NoError(t, err)
+		require.Equal(t, 1, c.Len())
+	})
+}
+
 func TestCacheMultithreading(_ *testing.T) {
 	c := NewCache(10)
 	wg := &sync.WaitGroup{}
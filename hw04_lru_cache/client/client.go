@@ -0,0 +1,308 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCacheMiss   = errors.New("cache miss")
+	ErrMalformed   = errors.New("malformed server response")
+	ErrBadKey      = errors.New("key is empty, too long or contains spaces or control characters")
+	ErrServerError = errors.New("server error")
+	ErrClientError = errors.New("client error")
+	ErrBroken      = errors.New("connection closed after a failed request")
+)
+
+const maxKeyLength = 250
+
+// Item is a value stored under Key. Expiration is in seconds; values above
+// 30 days are unix timestamps, zero means no expiration.
+type Item struct {
+	Key        string
+	Value      []byte
+	Flags      uint32
+	Expiration int32
+}
+
+// Client talks to a cache server over a single connection. It is safe for
+// concurrent use; requests are serialized. A network error, timeout or
+// malformed reply closes the connection, and later requests fail with
+// ErrBroken.
+type Client struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	rw     *bufio.ReadWriter
+	broken error
+	closed bool
+}
+
+// Dial connects to address. A positive timeout bounds the dial and every
+// following request.
+func Dial(address string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		timeout: timeout,
+		conn:    conn,
+		rw:      bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.broken != nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Get returns ErrCacheMiss when the key is not in the cache.
+func (c *Client) Get(key string) (*Item, error) {
+	items, err := c.GetMulti([]string{key})
+	if err != nil {
+		return nil, err
+	}
+	item, ok := items[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return item, nil
+}
+
+// GetMulti fetches several keys in one request. Missing keys are absent from
+// the result.
+func (c *Client) GetMulti(keys []string) (map[string]*Item, error) {
+	if len(keys) == 0 {
+		return map[string]*Item{}, nil
+	}
+	for _, key := range keys {
+		if !validKey(key) {
+			return nil, ErrBadKey
+		}
+	}
+
+	items := make(map[string]*Item, len(keys))
+	err := c.do(func(rw *bufio.ReadWriter) error {
+		if _, err := fmt.Fprintf(rw, "get %s\r\n", strings.Join(keys, " ")); err != nil {
+			return err
+		}
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+		for {
+			line, err := readLine(rw.Reader)
+			if err != nil {
+				return err
+			}
+			if line == "END" {
+				return nil
+			}
+			item, err := readValue(rw.Reader, line)
+			if err != nil {
+				return err
+			}
+			items[item.Key] = item
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (c *Client) Set(item *Item) error {
+	if !validKey(item.Key) {
+		return ErrBadKey
+	}
+
+	return c.do(func(rw *bufio.ReadWriter) error {
+		_, err := fmt.Fprintf(rw, "set %s %d %d %d\r\n",
+			item.Key, item.Flags, item.Expiration, len(item.Value))
+		if err != nil {
+			return err
+		}
+		if _, err := rw.Write(item.Value); err != nil {
+			return err
+		}
+		if _, err := rw.WriteString("\r\n"); err != nil {
+			return err
+		}
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		line, err := readLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+		if line != "STORED" {
+			return replyError(line)
+		}
+		return nil
+	})
+}
+
+// Delete returns ErrCacheMiss when the key is not in the cache.
+func (c *Client) Delete(key string) error {
+	if !validKey(key) {
+		return ErrBadKey
+	}
+
+	return c.do(func(rw *bufio.ReadWriter) error {
+		if _, err := fmt.Fprintf(rw, "delete %s\r\n", key); err != nil {
+			return err
+		}
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		line, err := readLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+		switch line {
+		case "DELETED":
+			return nil
+		case "NOT_FOUND":
+			return ErrCacheMiss
+		default:
+			return replyError(line)
+		}
+	})
+}
+
+// Stats returns the server statistics by name.
+func (c *Client) Stats() (map[string]string, error) {
+	stats := make(map[string]string)
+	err := c.do(func(rw *bufio.ReadWriter) error {
+		if _, err := rw.WriteString("stats\r\n"); err != nil {
+			return err
+		}
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+		for {
+			line, err := readLine(rw.Reader)
+			if err != nil {
+				return err
+			}
+			if line == "END" {
+				return nil
+			}
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) != 3 || fields[0] != "STAT" {
+				return replyError(line)
+			}
+			stats[fields[1]] = fields[2]
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (c *Client) do(fn func(rw *bufio.ReadWriter) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+	if c.broken != nil {
+		return fmt.Errorf("%w: %w", ErrBroken, c.broken)
+	}
+
+	var err error
+	if c.timeout > 0 {
+		err = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if err == nil {
+		err = fn(c.rw)
+	}
+	// Only complete error replies leave the stream in sync; after anything
+	// else the next reply could start with leftovers of this one.
+	if err != nil && !errors.Is(err, ErrCacheMiss) &&
+		!errors.Is(err, ErrServerError) && !errors.Is(err, ErrClientError) {
+		c.broken = err
+		c.conn.Close()
+	}
+	return err
+}
+
+// readValue reads the data block announced by a "VALUE <key> <flags> <bytes>"
+// line.
+func readValue(r *bufio.Reader, line string) (*Item, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "VALUE" {
+		return nil, replyError(line)
+	}
+	flags, err := strconv.ParseUint(fields[2], 10, 32)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	size, err := strconv.Atoi(fields[3])
+	if err != nil || size < 0 {
+		return nil, ErrMalformed
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		return nil, ErrMalformed
+	}
+	return &Item{Key: fields[1], Value: data[:size], Flags: uint32(flags)}, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// replyError converts an unexpected reply line into an error.
+func replyError(line string) error {
+	switch {
+	case strings.HasPrefix(line, "SERVER_ERROR "):
+		return fmt.Errorf("%w: %s", ErrServerError, strings.TrimPrefix(line, "SERVER_ERROR "))
+	case strings.HasPrefix(line, "CLIENT_ERROR "):
+		return fmt.Errorf("%w: %s", ErrClientError, strings.TrimPrefix(line, "CLIENT_ERROR "))
+	default:
+		return fmt.Errorf("%w: %q", ErrMalformed, line)
+	}
+}
+
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hw04lrucache "github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache"
+	"github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache/server"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, capacity int) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+
+	srv := server.New(hw04lrucache.NewGenericCache[string, server.Item](capacity), 16)
+	go srv.Serve(l)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, srv.Shutdown(ctx))
+	})
+	return l.Addr().String()
+}
+
+func TestClient(t *testing.T) {
+	address := startServer(t, 2)
+	c, err := Dial(address, 5*time.Second)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, c.Close()) }()
+
+	_, err = c.Get("a")
+	require.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, c.Set(&Item{Key: "a", Value: []byte("hello\r\nworld"), Flags: 42}))
+	require.NoError(t, c.Set(&Item{Key: "b", Value: []byte{}}))
+
+	item, err := c.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, &Item{Key: "a", Value: []byte("hello\r\nworld"), Flags: 42}, item)
+
+	items, err := c.GetMulti([]string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, []byte{}, items["b"].Value)
+
+	require.NoError(t, c.Delete("a"))
+	require.ErrorIs(t, c.Delete("a"), ErrCacheMiss)
+
+	require.NoError(t, c.Set(&Item{Key: "c", Value: []byte("1")}))
+	require.NoError(t, c.Set(&Item{Key: "d", Value: []byte("2")}))
+	_, err = c.Get("b")
+	require.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, c.Set(&Item{Key: "e", Value: []byte("3"), Expiration: -1}))
+	_, err = c.Get("e")
+	require.ErrorIs(t, err, ErrCacheMiss)
+
+	stats, err := c.Stats()
+	require.NoError(t, err)
+	require.Equal(t, "2", stats["curr_items"])
+	require.Equal(t, "1", stats["evictions"])
+	require.Equal(t, "1", stats["curr_connections"])
+}
+
+func TestClientErrors(t *testing.T) {
+	address := startServer(t, 2)
+	c, err := Dial(address, 5*time.Second)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, key := range []string{"", "a b", "a\nb", strings.Repeat("k", maxKeyLength+1)} {
+		require.ErrorIs(t, c.Set(&Item{Key: key}), ErrBadKey)
+		_, err = c.Get(key)
+		require.ErrorIs(t, err, ErrBadKey)
+		require.ErrorIs(t, c.Delete(key), ErrBadKey)
+	}
+
+	err = c.Set(&Item{Key: "big", Value: make([]byte, 17)})
+	require.ErrorIs(t, err, ErrServerError)
+	require.ErrorContains(t, err, "object too large for cache")
+
+	// The connection stays usable after an error.
+	require.NoError(t, c.Set(&Item{Key: "a", Value: []byte("1")}))
+
+	_, err = Dial("127.0.0.1:1", time.Second)
+	require.Error(t, err)
+}
+
+func TestClientConcurrent(t *testing.T) {
+	address := startServer(t, 100)
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c, err := Dial(address, 5*time.Second)
+			require.NoError(t, err)
+			defer c.Close()
+
+			var inner sync.WaitGroup
+			for j := range 4 {
+				inner.Add(1)
+				go func() {
+					defer inner.Done()
+
+					key := strings.Repeat("k", i+1) + strings.Repeat("j", j+1)
+					for range 50 {
+						require.NoError(t, c.Set(&Item{Key: key, Value: []byte(key)}))
+						item, err := c.Get(key)
+						require.NoError(t, err)
+						require.Equal(t, key, string(item.Value))
+					}
+				}()
+			}
+			inner.Wait()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClientBroken(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		reply string
+		err   error
+	}{
+		{name: "malformed reply", reply: "BOGUS\r\n", err: ErrMalformed},
+		{name: "truncated reply", reply: "VALUE a 0 5\r\nhe", err: os.ErrDeadlineExceeded},
+		{name: "closed connection", err: io.EOF},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:")
+			require.NoError(t, err)
+			defer l.Close()
+
+			served := make(chan struct{})
+			go func() {
+				defer close(served)
+				conn, err := l.Accept()
+				require.NoError(t, err)
+				defer conn.Close()
+
+				_, err = bufio.NewReader(conn).ReadString('\n')
+				require.NoError(t, err)
+				if tc.reply == "" {
+					return
+				}
+				conn.Write([]byte(tc.reply))
+				conn.Read(make([]byte, 1)) // hold the connection until the client closes it
+			}()
+
+			c, err := Dial(l.Addr().String(), 100*time.Millisecond)
+			require.NoError(t, err)
+
+			_, err = c.Get("a")
+			require.ErrorIs(t, err, tc.err)
+			<-served
+
+			_, err = c.Get("a")
+			require.ErrorIs(t, err, ErrBroken)
+			require.ErrorIs(t, err, tc.err)
+			require.NoError(t, c.Close())
+			require.ErrorIs(t, c.Delete("a"), net.ErrClosed)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/maphash"
+	"log"
+	"net"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	hw04lrucache "github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache"
+	"github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache/server"
+)
+
+var (
+	address         string
+	capacity        int
+	maxBytes        int64
+	maxItemSize     int
+	shards          int
+	shutdownTimeout time.Duration
+)
+
+func init() {
+	flag.StringVar(&address, "addr", "localhost:11211", "address to listen on")
+	flag.IntVar(&capacity, "capacity", 0, "maximum number of items, 0 for no item limit if -max-bytes is set")
+	flag.Int64Var(&maxBytes, "max-bytes", 64<<20, "memory budget for keys and data in bytes, 0 for no budget if -capacity is set")
+	flag.IntVar(&maxItemSize, "max-item-size", server.DefaultMaxItemSize, "maximum size of one item in bytes")
+	flag.IntVar(&shards, "shards", 0, "number of cache shards, 0 for GOMAXPROCS")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 10*time.Second, "time to finish requests on shutdown")
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	// A zero capacity without a budget would evict every item right away.
+	if capacity <= 0 && maxBytes <= 0 {
+		return errors.New("at least one of -capacity and -max-bytes must be positive")
+	}
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	if maxItemSize <= 0 {
+		maxItemSize = server.DefaultMaxItemSize
+	}
+	// Every shard gets an equal part of the budget and must fit the largest item.
+	if maxBytes > 0 && maxBytes/int64(shards) < int64(maxItemSize+server.MaxKeyLength) {
+		return fmt.Errorf("max-bytes %d split over %d shards cannot hold an item of max-item-size %d",
+			maxBytes, shards, maxItemSize)
+	}
+
+	seed := maphash.MakeSeed()
+	cache := hw04lrucache.NewGenericShardedCache[string, server.Item](capacity, shards,
+		func(key string) uint64 {
+			return maphash.String(seed, key)
+		},
+		hw04lrucache.WithMaxCost(maxBytes),
+		hw04lrucache.WithCost(server.Cost),
+		hw04lrucache.WithJanitor(time.Minute),
+	)
+	defer cache.Close()
+
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	log.Printf("listening on %s", l.Addr())
+
+	srv := server.New(cache, maxItemSize)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(l)
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, server.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package hw04lrucache
+
+// lfuBucket holds the items accessed freq times, most recently used first.
+type lfuBucket[K comparable, V any] struct {
+	freq  int
+	items GenericList[*cacheItem[K, V]]
+}
+
+// lfuPolicy evicts the least frequently used item, breaking ties by recency.
+// Buckets are kept in ascending frequency order, so every operation is O(1).
+type lfuPolicy[K comparable, V any] struct {
+	buckets GenericList[*lfuBucket[K, V]]
+	size    int
+}
+
+func NewLFUCache(capacity int, opts ...Option) Cache {
+	return NewGenericLFUCache[Key, interface{}](capacity, opts...)
+}
+
+func NewGenericLFUCache[K comparable, V any](capacity int, opts ...Option) GenericCache[K, V] {
+	return newCache[K, V](capacity, newLFUPolicy[K, V](), opts)
+}
+
+func newLFUPolicy[K comparable, V any]() *lfuPolicy[K, V] {
+	return &lfuPolicy[K, V]{buckets: NewGenericList[*lfuBucket[K, V]]()}
+}
+
+func (p *lfuPolicy[K, V]) admit(K) {}
+
+func (p *lfuPolicy[K, V]) add(item *cacheItem[K, V]) {
+	bucket := p.buckets.Front()
+	if bucket == nil || bucket.Value.freq != 1 {
+		bucket = p.buckets.PushFront(newLFUBucket[K, V](1))
+	}
+	p.attach(item, bucket)
+	p.size++
+}
+
+func (p *lfuPolicy[K, V]) access(item *cacheItem[K, V]) {
+	cur := item.bucket
+	next := cur.Next
+	if next == nil || next.Value.freq != cur.Value.freq+1 {
+		next = p.buckets.InsertAfter(newLFUBucket[K, V](cur.Value.freq+1), cur)
+	}
+	p.detach(item)
+	p.attach(item, next)
+}
+
+func (p *lfuPolicy[K, V]) remove(item *cacheItem[K, V]) {
+	p.detach(item)
+	p.size--
+}
+
+func (p *lfuPolicy[K, V]) evict() *cacheItem[K, V] {
+	item := p.first()
+	p.remove(item)
+	return item
+}
+
+func (p *lfuPolicy[K, V]) first() *cacheItem[K, V] {
+	bucket := p.buckets.Front()
+	if bucket == nil {
+		return nil
+	}
+	return bucket.Value.items.Back().Value
+}
+
+func (p *lfuPolicy[K, V]) next(item *cacheItem[K, V]) *cacheItem[K, V] {
+	if item.node.Prev != nil {
+		return item.node.Prev.Value
+	}
+	if bucket := item.bucket.Next; bucket != nil {
+		return bucket.Value.items.Back().Value
+	}
+	return nil
+}
+
+func (p *lfuPolicy[K, V]) len() int {
+	return p.size
+}
+
+func (p *lfuPolicy[K, V]) resize(int) {}
+
+func (p *lfuPolicy[K, V]) clear() {
+	p.buckets = NewGenericList[*lfuBucket[K, V]]()
+	p.size = 0
+}
+
+func newLFUBucket[K comparable, V any](freq int) *lfuBucket[K, V] {
+	return &lfuBucket[K, V]{freq: freq, items: NewGenericList[*cacheItem[K, V]]()}
+}
+
+func (p *lfuPolicy[K, V]) attach(item *cacheItem[K, V], bucket *GenericListItem[*lfuBucket[K, V]]) {
+	item.bucket = bucket
+	item.node = bucket.Value.items.PushFront(item)
+}
+
+// detach unlinks the item from its bucket and drops the bucket once it is empty.
+func (p *lfuPolicy[K, V]) detach(item *cacheItem[K, V]) {
+	bucket := item.bucket
+	bucket.Value.items.Remove(item.node)
+	if bucket.Value.items.Len() == 0 {
+		p.buckets.Remove(bucket)
+	}
+	item.bucket = nil
+	item.node = nil
+}
@@ -1,45 +1,54 @@
 package hw04lrucache
 
-type List interface {
+type List = GenericList[interface{}]
+
+type ListItem = GenericListItem[interface{}]
+
+type GenericList[T any] interface {
 	Len() int
-	Front() *ListItem
-	Back() *ListItem
-	PushFront(v interface{}) *ListItem
-	PushBack(v interface{}) *ListItem
-	Remove(i *ListItem)
-	MoveToFront(i *ListItem)
+	Front() *GenericListItem[T]
+	Back() *GenericListItem[T]
+	PushFront(v T) *GenericListItem[T]
+	PushBack(v T) *GenericListItem[T]
+	InsertAfter(v T, mark *GenericListItem[T]) *GenericListItem[T]
+	Remove(i *GenericListItem[T])
+	MoveToFront(i *GenericListItem[T])
 }
 
-type ListItem struct {
-	Value interface{}
-	Next  *ListItem
-	Prev  *ListItem
+type GenericListItem[T any] struct {
+	Value T
+	Next  *GenericListItem[T]
+	Prev  *GenericListItem[T]
 }
 
-type list struct {
-	front *ListItem
-	back  *ListItem
+type list[T any] struct {
+	front *GenericListItem[T]
+	back  *GenericListItem[T]
 	len   int
 }
 
 func NewList() List {
-	return &list{len: 0}
+	return NewGenericList[interface{}]()
 }
 
-func (l *list) Len() int {
+func NewGenericList[T any]() GenericList[T] {
+	return &list[T]{len: 0}
+}
+
+func (l *list[T]) Len() int {
 	return l.len
 }
 
-func (l *list) Front() *ListItem {
+func (l *list[T]) Front() *GenericListItem[T] {
 	return l.front
 }
 
-func (l *list) Back() *ListItem {
+func (l *list[T]) Back() *GenericListItem[T] {
 	return l.back
 }
 
-func (l *list) PushFront(v interface{}) *ListItem {
-	item := ListItem{Value: v, Next: l.front}
+func (l *list[T]) PushFront(v T) *GenericListItem[T] {
+	item := GenericListItem[T]{Value: v, Next: l.front}
 	if l.front != nil {
 		l.front.Prev = &item
 	}
@@ -52,8 +61,8 @@ func (l *list) PushFront(v interface{}) *ListItem {
 	return &item
 }
 
-func (l *list) PushBack(v interface{}) *ListItem {
-	item := ListItem{Value: v, Prev: l.back}
+func (l *list[T]) PushBack(v T) *GenericListItem[T] {
+	item := GenericListItem[T]{Value: v, Prev: l.back}
 	if l.back != nil {
 		l.back.Next = &item
 	}
@@ -66,7 +75,19 @@ func (l *list) PushBack(v interface{}) *ListItem {
 	return &item
 }
 
-func (l *list) Remove(i *ListItem) {
+func (l *list[T]) InsertAfter(v T, mark *GenericListItem[T]) *GenericListItem[T] {
+	if mark == l.back {
+		return l.PushBack(v)
+	}
+
+	item := GenericListItem[T]{Value: v, Prev: mark, Next: mark.Next}
+	mark.Next.Prev = &item
+	mark.Next = &item
+	l.len++
+	return &item
+}
+
+func (l *list[T]) Remove(i *GenericListItem[T]) {
 	if i == nil {
 		return
 	}
@@ -88,7 +109,7 @@ func (l *list) Remove(i *ListItem) {
 	l.len--
 }
 
-func (l *list) MoveToFront(i *ListItem) {
+func (l *list[T]) MoveToFront(i *GenericListItem[T]) {
 	if i == nil || i == l.front {
 		return
 	}
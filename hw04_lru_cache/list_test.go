@@ -58,3 +58,34 @@ func TestList(t *testing.T) {
 		require.Equal(t, 10, l.Back().Value)
 	})
 }
+
+func TestGenericList(t *testing.T) {
+	l := NewGenericList[string]()
+
+	l.PushBack("b")  // [b]
+	l.PushFront("a") // [a, b]
+	c := l.PushBack("c")
+	l.MoveToFront(c) // [c, a, b]
+
+	elems := make([]string, 0, l.Len())
+	for i := l.Front(); i != nil; i = i.Next {
+		elems = append(elems, i.Value)
+	}
+	require.Equal(t, []string{"c", "a", "b"}, elems)
+
+	l.Remove(l.Back()) // [c, a]
+	require.Equal(t, 2, l.Len())
+	require.Equal(t, "a", l.Back().Value)
+
+	l.InsertAfter("x", l.Front()) // [c, x, a]
+	l.InsertAfter("y", l.Back())  // [c, x, a, y]
+
+	elems = elems[:0]
+	for i := l.Front(); i != nil; i = i.Next {
+		elems = append(elems, i.Value)
+	}
+	require.Equal(t, []string{"c", "x", "a", "y"}, elems)
+	require.Equal(t, 4, l.Len())
+	require.Equal(t, "y", l.Back().Value)
+	require.Equal(t, "a", l.Back().Prev.Value)
+}
@@ -0,0 +1,116 @@
+package hw04lrucache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+var ErrLoaderPanic = errors.New("cache loader panicked")
+
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// load is a loader call shared by every GetOrLoad waiting for the same key.
+type load[V any] struct {
+	done  chan struct{}
+	ttl   time.Duration
+	value V
+	err   error
+}
+
+type failure struct {
+	err       error
+	expiresAt int64
+}
+
+// GetOrLoad returns the cached value or calls loader to obtain and store it.
+// Concurrent calls for the same key share one loader call. The loader runs
+// detached from ctx cancellation, so a caller giving up does not fail the
+// others; it only stops waiting and gets ctx.Err().
+func (c *cache[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	c.mutex.Lock()
+	if item := c.lookup(key); item != nil {
+		c.policy.access(item)
+		c.hits.Add(1)
+		if c.refreshDue(item) {
+			c.startLoad(ctx, key, loader, item.ttl)
+		}
+		value := item.value
+		c.unlock()
+		return value, nil
+	}
+	c.misses.Add(1)
+
+	var zero V
+	if f, ok := c.failures[key]; ok {
+		if c.now().UnixNano() < f.expiresAt {
+			c.unlock()
+			return zero, f.err
+		}
+		delete(c.failures, key)
+	}
+
+	l := c.startLoad(ctx, key, loader, c.ttl)
+	c.unlock()
+
+	select {
+	case <-l.done:
+		return l.value, l.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+func (c *cache[K, V]) refreshDue(item *cacheItem[K, V]) bool {
+	return c.refresh > 0 && item.expiresAt != 0 &&
+		c.now().UnixNano() >= item.expiresAt-c.refresh.Nanoseconds()
+}
+
+// startLoad returns the in-flight load for key, starting one if there is none.
+// The loaded value is stored for ttl.
+func (c *cache[K, V]) startLoad(ctx context.Context, key K, loader Loader[K, V], ttl time.Duration) *load[V] {
+	if l, ok := c.loads[key]; ok {
+		return l
+	}
+	l := &load[V]{done: make(chan struct{}), ttl: ttl}
+	c.loads[key] = l
+	go c.runLoad(context.WithoutCancel(ctx), key, loader, l)
+	return l
+}
+
+func (c *cache[K, V]) runLoad(ctx context.Context, key K, loader Loader[K, V], l *load[V]) {
+	defer close(l.done)
+	l.value, l.err = callLoader(ctx, key, loader)
+
+	c.mutex.Lock()
+	defer c.unlock()
+
+	// Set, Delete, Clear and Load drop the in-flight load of the keys they
+	// change; its result is then older than the cache contents.
+	if c.loads[key] != l {
+		return
+	}
+	delete(c.loads, key)
+	if l.err == nil {
+		c.store(key, l.value, l.ttl, c.expiry(l.ttl), c.entryCost(key, l.value))
+	} else if c.negTTL > 0 {
+		c.failures[key] = failure{
+			err:       l.err,
+			expiresAt: c.now().Add(c.negTTL).UnixNano(),
+		}
+	}
+}
+
+// callLoader turns a loader panic into an ErrLoaderPanic error for the waiters
+// instead of crashing the process from the load goroutine.
+func callLoader[K comparable, V any](ctx context.Context, key K, loader Loader[K, V]) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero V
+			value, err = zero, fmt.Errorf("%w: %v\n%s", ErrLoaderPanic, r, debug.Stack())
+		}
+	}()
+	return loader(ctx, key)
+}
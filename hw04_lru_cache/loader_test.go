@@ -0,0 +1,319 @@
+package hw04lrucache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errLoad = errors.New("load failed")
+
+func TestGetOrLoad(t *testing.T) {
+	t.Run("loads and caches", func(t *testing.T) {
+		c := NewGenericCache[string, int](3)
+		var calls int
+		loader := func(_ context.Context, key string) (int, error) {
+			calls++
+			return len(key), nil
+		}
+
+		val, err := c.GetOrLoad(context.Background(), "abc", loader)
+		require.NoError(t, err)
+		require.Equal(t, 3, val)
+
+		val, err = c.GetOrLoad(context.Background(), "abc", loader)
+		require.NoError(t, err)
+		require.Equal(t, 3, val)
+		require.Equal(t, 1, calls)
+		require.Equal(t, Stats{Hits: 1, Misses: 1, Size: 1, HitRatio: 0.5}, c.Stats())
+	})
+
+	t.Run("concurrent loads are deduplicated", func(t *testing.T) {
+		c := NewGenericCache[string, int](3)
+		var calls atomic.Int32
+		release := make(chan struct{})
+		loader := func(_ context.Context, _ string) (int, error) {
+			calls.Add(1)
+			<-release
+			return 42, nil
+		}
+
+		wg := &sync.WaitGroup{}
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				val, err := c.GetOrLoad(context.Background(), "key", loader)
+				require.NoError(t, err)
+				require.Equal(t, 42, val)
+			}()
+		}
+		require.Eventually(t, func() bool {
+			return calls.Load() == 1
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("canceled caller does not cancel the load", func(t *testing.T) {
+		c := NewGenericCache[string, int](3)
+		release := make(chan struct{})
+		loaded := make(chan error, 1)
+		loader := func(ctx context.Context, _ string) (int, error) {
+			<-release
+			loaded <- ctx.Err()
+			return 1, nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			_, err := c.GetOrLoad(ctx, "key", loader)
+			done <- err
+		}()
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+
+		close(release)
+		require.NoError(t, <-loaded)
+		require.Eventually(t, func() bool {
+			return c.Contains("key")
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		c := NewShardedCache(8, 4)
+		val, err := c.GetOrLoad(context.Background(), "key", func(context.Context, Key) (interface{}, error) {
+			return "value", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "value", val)
+		require.True(t, c.Contains("key"))
+	})
+}
+
+func TestGetOrLoadErrors(t *testing.T) {
+	t.Run("errors are not cached by default", func(t *testing.T) {
+		c := NewGenericCache[string, int](3)
+		var calls int
+		loader := func(_ context.Context, _ string) (int, error) {
+			calls++
+			return 0, errLoad
+		}
+
+		_, err := c.GetOrLoad(context.Background(), "key", loader)
+		require.ErrorIs(t, err, errLoad)
+		_, err = c.GetOrLoad(context.Background(), "key", loader)
+		require.ErrorIs(t, err, errLoad)
+		require.Equal(t, 2, calls)
+		require.False(t, c.Contains("key"))
+	})
+
+	t.Run("negative ttl", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewGenericCache[string, int](3, WithNegativeTTL(time.Second), withClock(clock.Now))
+		var calls int
+		loader := func(_ context.Context, _ string) (int, error) {
+			calls++
+			if calls == 1 {
+				return 0, errLoad
+			}
+			return 7, nil
+		}
+
+		_, err := c.GetOrLoad(context.Background(), "key", loader)
+		require.ErrorIs(t, err, errLoad)
+		_, err = c.GetOrLoad(context.Background(), "key", loader)
+		require.ErrorIs(t, err, errLoad)
+		require.Equal(t, 1, calls)
+
+		clock.Add(time.Second)
+		val, err := c.GetOrLoad(context.Background(), "key", loader)
+		require.NoError(t, err)
+		require.Equal(t, 7, val)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("set clears a cached error", func(t *testing.T) {
+		c := NewGenericCache[string, int](3, WithNegativeTTL(time.Hour))
+		loader := func(_ context.Context, _ string) (int, error) {
+			return 0, errLoad
+		}
+
+		_, err := c.GetOrLoad(context.Background(), "key", loader)
+		require.ErrorIs(t, err, errLoad)
+		c.Set("key", 1)
+		c.Delete("key")
+
+		_, err = c.GetOrLoad(context.Background(), "key", func(context.Context, string) (int, error) {
+			return 2, nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("loader panic", func(t *testing.T) {
+		c := NewGenericCache[string, int](3)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		var once sync.Once
+		loader := func(_ context.Context, _ string) (int, error) {
+			once.Do(func() { close(started) })
+			<-release
+			panic("boom")
+		}
+
+		wg := &sync.WaitGroup{}
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.GetOrLoad(context.Background(), "key", loader)
+				require.ErrorIs(t, err, ErrLoaderPanic)
+				require.ErrorContains(t, err, "boom")
+			}()
+		}
+		<-started
+		close(release)
+		wg.Wait()
+		require.False(t, c.Contains("key"))
+
+		val, err := c.GetOrLoad(context.Background(), "key", func(context.Context, string) (int, error) {
+			return 1, nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, val)
+	})
+}
+
+func TestGetOrLoadRefreshAhead(t *testing.T) {
+	t.Run("refresh ahead", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewGenericCache[string, int](3,
+			WithTTL(time.Minute), WithRefreshAhead(10*time.Second), withClock(clock.Now))
+		var calls atomic.Int32
+		loader := func(_ context.Context, _ string) (int, error) {
+			return int(calls.Add(1)), nil
+		}
+
+		val, err := c.GetOrLoad(context.Background(), "key", loader)
+		require.NoError(t, err)
+		require.Equal(t, 1, val)
+
+		clock.Add(49 * time.Second)
+		val, _ = c.GetOrLoad(context.Background(), "key", loader)
+		require.Equal(t, 1, val)
+		require.Equal(t, int32(1), calls.Load())
+
+		clock.Add(time.Second)
+		val, _ = c.GetOrLoad(context.Background(), "key", loader)
+		require.Equal(t, 1, val) // served from the cache while refreshing
+		require.Eventually(t, func() bool {
+			v, _ := c.Peek("key")
+			return v == 2
+		}, time.Second, time.Millisecond)
+
+		clock.Add(30 * time.Second) // the refreshed entry has a new TTL
+		val, _ = c.GetOrLoad(context.Background(), "key", loader)
+		require.Equal(t, 2, val)
+	})
+
+	t.Run("refresh keeps the entry ttl", func(t *testing.T) {
+		clock := newFakeClock()
+		c := NewGenericCache[string, int](3, WithRefreshAhead(10*time.Second), withClock(clock.Now))
+		c.SetWithTTL("key", 1, time.Minute)
+		loader := func(_ context.Context, _ string) (int, error) {
+			return 2, nil
+		}
+
+		clock.Add(50 * time.Second)
+		val, err := c.GetOrLoad(context.Background(), "key", loader)
+		require.NoError(t, err)
+		require.Equal(t, 1, val)
+		require.Eventually(t, func() bool {
+			v, _ := c.Peek("key")
+			return v == 2
+		}, time.Second, time.Millisecond)
+
+		clock.Add(59 * time.Second)
+		require.True(t, c.Contains("key"))
+		clock.Add(time.Second)
+		require.False(t, c.Contains("key"))
+	})
+}
+
+func TestGetOrLoadInvalidation(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		change func(c GenericCache[string, int])
+		want   map[string]int
+	}{
+		{
+			name:   "set during load",
+			change: func(c GenericCache[string, int]) { c.Set("key", 2) },
+			want:   map[string]int{"key": 2},
+		},
+		{
+			name:   "delete during load",
+			change: func(c GenericCache[string, int]) { c.Delete("key") },
+			want:   map[string]int{},
+		},
+		{
+			name: "rejected set during load",
+			change: func(c GenericCache[string, int]) {
+				_, err := c.SetWithCost("key", 2, -1)
+				require.ErrorIs(t, err, ErrNegativeCost)
+			},
+			want: map[string]int{"key": 1},
+		},
+		{
+			name:   "clear during load",
+			change: func(c GenericCache[string, int]) { c.Clear() },
+			want:   map[string]int{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewGenericCache[string, int](3)
+			started := make(chan struct{})
+			release := make(chan struct{})
+			loader := func(_ context.Context, _ string) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			}
+
+			result := make(chan int)
+			go func() {
+				val, err := c.GetOrLoad(context.Background(), "key", loader)
+				require.NoError(t, err)
+				result <- val
+			}()
+			<-started
+			tc.change(c)
+			close(release)
+			require.Equal(t, 1, <-result)
+
+			got := make(map[string]int)
+			for k, v := range c.All() {
+				got[k] = v
+			}
+			require.Equal(t, tc.want, got)
+
+			val, err := c.GetOrLoad(context.Background(), "key", func(context.Context, string) (int, error) {
+				return 3, nil
+			})
+			require.NoError(t, err)
+			if want, ok := tc.want["key"]; ok {
+				require.Equal(t, want, val)
+			} else {
+				require.Equal(t, 3, val)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package hw04lrucache
+
+import "time"
+
+type Option func(*options)
+
+type options struct {
+	ttl             time.Duration
+	janitorInterval time.Duration
+	now             func() time.Time
+	onEvict         interface{}
+	maxCost         int64
+	cost            interface{}
+	negativeTTL     time.Duration
+	refreshAhead    time.Duration
+	codec           Codec
+}
+
+func newOptions(opts []Option) options {
+	o := options{now: time.Now, codec: GobCodec}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTTL sets the expiration applied by Set. Zero means entries never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithJanitor starts a goroutine removing expired entries every interval.
+// It runs until Close is called.
+func WithJanitor(interval time.Duration) Option {
+	return func(o *options) {
+		o.janitorInterval = interval
+	}
+}
+
+// WithOnEvict registers fn to be called for every entry leaving the cache.
+// Its key and value types must match the cache ones. fn is called after the
+// cache lock is released, so it may use the cache.
+func WithOnEvict[K comparable, V any](fn func(key K, value V, reason EvictReason)) Option {
+	return func(o *options) {
+		o.onEvict = fn
+	}
+}
+
+// WithMaxCost bounds the cache by the total cost of its entries instead of
+// only their number. Each entry costs 1 unless WithCost or SetWithCost says
+// otherwise. A non-positive capacity disables the item count limit then.
+// A sharded cache divides the budget equally between its shards.
+func WithMaxCost(maxCost int64) Option {
+	return func(o *options) {
+		o.maxCost = maxCost
+	}
+}
+
+// WithCost sets the function computing the cost of entries added by Set and
+// SetWithTTL. Its key and value types must match the cache ones.
+func WithCost[K comparable, V any](fn func(key K, value V) int64) Option {
+	return func(o *options) {
+		o.cost = fn
+	}
+}
+
+// WithNegativeTTL makes GetOrLoad remember loader errors for ttl and return
+// them without calling the loader again.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithRefreshAhead makes GetOrLoad reload entries in the background once
+// they are within window of their expiration, serving the cached value
+// meanwhile.
+func WithRefreshAhead(window time.Duration) Option {
+	return func(o *options) {
+		o.refreshAhead = window
+	}
+}
+
+// WithCodec sets the encoding used by Save and Load. GobCodec is the default.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+func withClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func onEvictFunc[K comparable, V any](o options) func(K, V, EvictReason) {
+	if o.onEvict == nil {
+		return nil
+	}
+	fn, ok := o.onEvict.(func(K, V, EvictReason))
+	if !ok {
+		panic("hw04lrucache: OnEvict callback does not match cache key and value types")
+	}
+	return fn
+}
+
+func costFunc[K comparable, V any](o options) func(K, V) int64 {
+	if o.cost == nil {
+		return nil
+	}
+	fn, ok := o.cost.(func(K, V) int64)
+	if !ok {
+		panic("hw04lrucache: cost function does not match cache key and value types")
+	}
+	return fn
+}
@@ -0,0 +1,72 @@
+package hw04lrucache
+
+// policy orders cache items for eviction. The cache calls it under its mutex.
+type policy[K comparable, V any] interface {
+	// admit is called with the key of a new item before room is made for it.
+	admit(key K)
+	add(item *cacheItem[K, V])
+	access(item *cacheItem[K, V])
+	remove(item *cacheItem[K, V])
+	// evict detaches and returns the next victim.
+	evict() *cacheItem[K, V]
+	// first and next walk the items from the first to the last candidate for eviction.
+	first() *cacheItem[K, V]
+	next(item *cacheItem[K, V]) *cacheItem[K, V]
+	len() int
+	resize(capacity int)
+	clear()
+}
+
+type lruPolicy[K comparable, V any] struct {
+	queue GenericList[*cacheItem[K, V]]
+}
+
+func newLRUPolicy[K comparable, V any]() *lruPolicy[K, V] {
+	return &lruPolicy[K, V]{queue: NewGenericList[*cacheItem[K, V]]()}
+}
+
+func (p *lruPolicy[K, V]) admit(K) {}
+
+func (p *lruPolicy[K, V]) add(item *cacheItem[K, V]) {
+	item.node = p.queue.PushFront(item)
+}
+
+func (p *lruPolicy[K, V]) access(item *cacheItem[K, V]) {
+	p.queue.MoveToFront(item.node)
+}
+
+func (p *lruPolicy[K, V]) remove(item *cacheItem[K, V]) {
+	p.queue.Remove(item.node)
+}
+
+func (p *lruPolicy[K, V]) evict() *cacheItem[K, V] {
+	item := p.queue.Back().Value
+	p.queue.Remove(item.node)
+	return item
+}
+
+func (p *lruPolicy[K, V]) first() *cacheItem[K, V] {
+	return value(p.queue.Back())
+}
+
+func (p *lruPolicy[K, V]) next(item *cacheItem[K, V]) *cacheItem[K, V] {
+	return value(item.node.Prev)
+}
+
+func (p *lruPolicy[K, V]) len() int {
+	return p.queue.Len()
+}
+
+func (p *lruPolicy[K, V]) resize(int) {}
+
+func (p *lruPolicy[K, V]) clear() {
+	p.queue = NewGenericList[*cacheItem[K, V]]()
+}
+
+// value returns the item held by a list node, or nil for a nil node.
+func value[K comparable, V any](node *GenericListItem[*cacheItem[K, V]]) *cacheItem[K, V] {
+	if node == nil {
+		return nil
+	}
+	return node.Value
+}
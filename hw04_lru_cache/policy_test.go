@@ -0,0 +1,247 @@
+package hw04lrucache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var policies = []struct {
+	name     string
+	newCache func(capacity int, opts ...Option) Cache
+}{
+	{"lru", NewCache},
+	{"lfu", NewLFUCache},
+	{"2q", New2QCache},
+	{"arc", NewARCCache},
+}
+
+// TestPolicies checks the behaviour every eviction policy has to share.
+func TestPolicies(t *testing.T) {
+	for _, p := range policies {
+		t.Run(p.name, func(t *testing.T) {
+			t.Run("simple", func(t *testing.T) {
+				c := p.newCache(5)
+
+				wasInCache := c.Set("aaa", 100)
+				require.False(t, wasInCache)
+				wasInCache = c.Set("aaa", 200)
+				require.True(t, wasInCache)
+
+				val, ok := c.Get("aaa")
+				require.True(t, ok)
+				require.Equal(t, 200, val)
+
+				val, ok = c.Get("bbb")
+				require.False(t, ok)
+				require.Nil(t, val)
+			})
+
+			t.Run("capacity", func(t *testing.T) {
+				var evicted int
+				c := p.newCache(10, WithOnEvict(func(Key, interface{}, EvictReason) {
+					evicted++
+				}))
+				for i := range 100 {
+					c.Set(Key(strconv.Itoa(i)), i)
+					if i%3 == 0 {
+						c.Get(Key(strconv.Itoa(i / 2)))
+					}
+				}
+
+				require.Equal(t, 10, c.Len())
+				require.Len(t, c.Keys(), 10)
+				require.Equal(t, 90, evicted)
+				require.Equal(t, uint64(90), c.Stats().Evictions)
+
+				for k, v := range c.All() {
+					require.Equal(t, string(k), strconv.Itoa(v.(int)))
+				}
+			})
+
+			t.Run("delete and peek", func(t *testing.T) {
+				c := p.newCache(3)
+				c.Set("a", 1)
+				c.Set("b", 2)
+
+				val, ok := c.Peek("a")
+				require.True(t, ok)
+				require.Equal(t, 1, val)
+				require.True(t, c.Delete("a"))
+				require.False(t, c.Contains("a"))
+				require.ElementsMatch(t, []Key{"b"}, c.Keys())
+				require.Equal(t, Stats{Size: 1}, c.Stats())
+			})
+
+			t.Run("ttl", func(t *testing.T) {
+				clock := newFakeClock()
+				c := p.newCache(3, withClock(clock.Now))
+				c.SetWithTTL("a", 1, time.Second)
+				c.Set("b", 2)
+
+				clock.Add(time.Second)
+				require.False(t, c.Contains("a"))
+				require.True(t, c.Contains("b"))
+			})
+
+			t.Run("cost", func(t *testing.T) {
+				c := p.newCache(0, WithMaxCost(10))
+				for i := range 20 {
+					_, err := c.SetWithCost(Key(strconv.Itoa(i)), i, int64(i%4+1))
+					require.NoError(t, err)
+				}
+
+				var cost int64
+				for k := range c.All() {
+					i, _ := strconv.Atoi(string(k))
+					cost += int64(i%4 + 1)
+				}
+				require.LessOrEqual(t, cost, int64(10))
+				require.Contains(t, c.Keys(), Key("19"))
+
+				_, err := c.SetWithCost("big", 0, 11)
+				require.ErrorIs(t, err, ErrTooLarge)
+			})
+
+			t.Run("resize and clear", func(t *testing.T) {
+				c := p.newCache(10)
+				for i := range 10 {
+					c.Set(Key(strconv.Itoa(i)), i)
+				}
+
+				require.Equal(t, 6, c.Resize(4))
+				require.Equal(t, 4, c.Len())
+				c.Set("x", 0)
+				require.Equal(t, 4, c.Len())
+
+				c.Clear()
+				require.Equal(t, 0, c.Len())
+				require.Empty(t, c.Keys())
+				c.Set("y", 1)
+				require.Equal(t, []Key{"y"}, c.Keys())
+			})
+
+			t.Run("janitor", func(t *testing.T) {
+				clock := newFakeClock()
+				c := p.newCache(200, WithJanitor(time.Millisecond), withClock(clock.Now))
+				defer c.Close()
+				for i := range 200 {
+					c.SetWithTTL(Key(strconv.Itoa(i)), i, time.Duration(i%2)*time.Second)
+					c.Get(Key(strconv.Itoa(i / 3)))
+				}
+				clock.Add(time.Second)
+
+				require.Eventually(t, func() bool {
+					return c.Len() == 100
+				}, time.Second, time.Millisecond)
+			})
+
+			t.Run("multithreading", func(t *testing.T) {
+				c := p.newCache(50)
+				wg := &sync.WaitGroup{}
+				for g := range 4 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := range 5_000 {
+							key := Key(strconv.Itoa((g*7 + i) % 200))
+							c.Set(key, i)
+							c.Get(key)
+							if i%50 == 0 {
+								c.Delete(key)
+							}
+						}
+					}()
+				}
+				wg.Wait()
+
+				require.LessOrEqual(t, c.Len(), 50)
+				require.Len(t, c.Keys(), c.Len())
+			})
+		})
+	}
+}
+
+func TestLFUCache(t *testing.T) {
+	c := NewLFUCache(3)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Set("d", 4) // c has the lowest frequency
+
+	require.False(t, c.Contains("c"))
+	require.Equal(t, []Key{"a", "b", "d"}, c.Keys())
+
+	c.Get("d")
+	c.Set("e", 5) // b and d are tied, b is the least recently used
+
+	require.Equal(t, []Key{"a", "d", "e"}, c.Keys())
+}
+
+func TestTwoQueueCache(t *testing.T) {
+	t.Run("scan resistance", func(t *testing.T) {
+		c := New2QCache(4) // kin = 1, kout = 2
+		for _, k := range []Key{"a", "b", "c", "d", "e"} {
+			c.Set(k, 0)
+		}
+		require.False(t, c.Contains("a"))
+
+		c.Set("a", 1) // remembered in a1out, goes to am
+		for i := range 20 {
+			c.Set(Key("scan"+strconv.Itoa(i)), i)
+		}
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("a1in hits do not promote", func(t *testing.T) {
+		c := New2QCache(4)
+		c.Set("a", 1)
+		c.Get("a")
+		for i := range 4 {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+
+		require.False(t, c.Contains("a"))
+	})
+}
+
+func TestARCCache(t *testing.T) {
+	t.Run("scan resistance", func(t *testing.T) {
+		c := NewARCCache(4)
+		c.Set("a", 1)
+		c.Get("a") // moves to t2
+		for i := range 20 {
+			c.Set(Key("scan"+strconv.Itoa(i)), i)
+		}
+
+		val, ok := c.Get("a")
+		require.True(t, ok)
+		require.Equal(t, 1, val)
+	})
+
+	t.Run("adapts to recency", func(t *testing.T) {
+		c := NewARCCache(4).(*cache[Key, interface{}])
+		p := c.policy.(*arcPolicy[Key, interface{}])
+		c.Set("a", 0)
+		c.Get("a") // t2 = [a]
+		for i := range 4 {
+			c.Set(Key(strconv.Itoa(i)), i)
+		} // t1 = [3, 2, 1], b1 = [0]
+		require.Equal(t, 0, p.p)
+		require.Equal(t, 1, p.b1.len())
+
+		c.Set("0", 0) // ghost hit in b1 grows the target size of t1
+		require.Equal(t, 1, p.p)
+		require.Equal(t, queueT2, c.items["0"].queue)
+		require.Equal(t, 4, c.Len())
+	})
+}
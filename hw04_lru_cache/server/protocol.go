@@ -0,0 +1,240 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	hw04lrucache "github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache"
+)
+
+const (
+	MaxKeyLength = 250
+
+	// Expiration times above this many seconds are unix timestamps.
+	maxRelativeExpiration = 60 * 60 * 24 * 30
+)
+
+// handle executes one command line and reports whether the connection
+// should stay open.
+func (c *conn) handle(line []byte) bool {
+	fields := bytes.Fields(line)
+	if len(fields) == 0 {
+		c.w.WriteString("ERROR\r\n")
+		return true
+	}
+
+	switch string(fields[0]) {
+	case "get":
+		c.get(fields[1:])
+	case "set":
+		return c.set(fields[1:])
+	case "delete":
+		c.delete(fields[1:])
+	case "stats":
+		c.stats(fields[1:])
+	case "quit":
+		return false
+	default:
+		c.w.WriteString("ERROR\r\n")
+	}
+	return true
+}
+
+// get handles "get <key>*".
+func (c *conn) get(keys [][]byte) {
+	if len(keys) == 0 {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+	for _, key := range keys {
+		if !validKey(key) {
+			c.w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+
+	for _, key := range keys {
+		c.server.cmdGet.Add(1)
+		item, ok := c.server.cache.Get(string(key))
+		if !ok {
+			continue
+		}
+		c.w.WriteString("VALUE ")
+		c.w.Write(key)
+		c.w.WriteString(" " + strconv.FormatUint(uint64(item.Flags), 10))
+		c.w.WriteString(" " + strconv.Itoa(len(item.Data)) + "\r\n")
+		c.w.Write(item.Data)
+		c.w.WriteString("\r\n")
+	}
+	c.w.WriteString("END\r\n")
+}
+
+// set handles "set <key> <flags> <exptime> <bytes> [noreply]" followed by
+// the data block.
+func (c *conn) set(args [][]byte) bool {
+	// Without a valid size the data block cannot be told from the next
+	// command, so the connection is closed.
+	if len(args) < 4 {
+		c.w.WriteString("ERROR\r\n")
+		return false
+	}
+	// Like memcached, the size is a 32-bit value, so skipping size+2 bytes
+	// cannot overflow.
+	size32, err := strconv.ParseInt(string(args[3]), 10, 32)
+	if err != nil || size32 < 0 {
+		c.w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return false
+	}
+	size := int(size32)
+
+	// The arguments point into the read buffer, which the data block reuses.
+	key := string(args[0])
+	noreply := len(args) == 5 && string(args[4]) == "noreply"
+	flags, flagsErr := strconv.ParseUint(string(args[1]), 10, 32)
+	exptime, exptimeErr := strconv.ParseInt(string(args[2]), 10, 64)
+	switch {
+	case len(args) > 4 && !noreply:
+		return c.skipData(size, "ERROR\r\n")
+	case !validKey(args[0]) || flagsErr != nil || exptimeErr != nil:
+		return c.skipData(size, "CLIENT_ERROR bad command line format\r\n")
+	case size > c.server.maxItemSize:
+		if noreply {
+			return c.skipData(size, "")
+		}
+		return c.skipData(size, "SERVER_ERROR object too large for cache\r\n")
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return false
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		// Drop the rest of an oversized data line instead of parsing it as
+		// a command.
+		if data[len(data)-1] != '\n' {
+			if _, err := c.readLine(); err != nil {
+				return false
+			}
+		}
+		c.w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return true
+	}
+
+	c.server.cmdSet.Add(1)
+	ttl, expired := expiration(exptime, time.Now())
+	if expired {
+		c.server.cache.Delete(key)
+		c.reply(noreply, "STORED\r\n")
+		return true
+	}
+
+	item := Item{Flags: uint32(flags), Data: data[:size]}
+	_, err = c.server.cache.SetWithTTLAndCost(key, item, ttl, Cost(key, item))
+	switch {
+	case errors.Is(err, hw04lrucache.ErrTooLarge):
+		c.reply(noreply, "SERVER_ERROR object too large for cache\r\n")
+	case err != nil:
+		c.reply(noreply, "SERVER_ERROR "+err.Error()+"\r\n")
+	default:
+		c.reply(noreply, "STORED\r\n")
+	}
+	return true
+}
+
+// skipData discards the data block of a rejected set so that it is not read
+// as a command, then writes the reply.
+func (c *conn) skipData(size int, reply string) bool {
+	if _, err := io.CopyN(io.Discard, c.r, int64(size)+2); err != nil {
+		return false
+	}
+	c.w.WriteString(reply)
+	return true
+}
+
+// delete handles "delete <key> [noreply]".
+func (c *conn) delete(args [][]byte) {
+	noreply := len(args) == 2 && string(args[1]) == "noreply"
+	if len(args) != 1 && !noreply {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+	if !validKey(args[0]) {
+		c.w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	}
+
+	if c.server.cache.Delete(string(args[0])) {
+		c.reply(noreply, "DELETED\r\n")
+	} else {
+		c.reply(noreply, "NOT_FOUND\r\n")
+	}
+}
+
+func (c *conn) stats(args [][]byte) {
+	if len(args) != 0 {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+
+	s := c.server
+	now := time.Now()
+	st := s.cache.Stats()
+	for _, stat := range []struct {
+		name  string
+		value string
+	}{
+		{"pid", strconv.Itoa(os.Getpid())},
+		{"uptime", strconv.FormatInt(int64(now.Sub(s.started)/time.Second), 10)},
+		{"time", strconv.FormatInt(now.Unix(), 10)},
+		{"curr_items", strconv.Itoa(st.Size)},
+		{"get_hits", strconv.FormatUint(st.Hits, 10)},
+		{"get_misses", strconv.FormatUint(st.Misses, 10)},
+		{"evictions", strconv.FormatUint(st.Evictions, 10)},
+		{"curr_connections", strconv.Itoa(s.currConns())},
+		{"total_connections", strconv.FormatUint(s.totalConns.Load(), 10)},
+		{"cmd_get", strconv.FormatUint(s.cmdGet.Load(), 10)},
+		{"cmd_set", strconv.FormatUint(s.cmdSet.Load(), 10)},
+	} {
+		c.w.WriteString("STAT " + stat.name + " " + stat.value + "\r\n")
+	}
+	c.w.WriteString("END\r\n")
+}
+
+func (c *conn) reply(noreply bool, msg string) {
+	if !noreply {
+		c.w.WriteString(msg)
+	}
+}
+
+// expiration converts a memcached exptime into a ttl. Zero means no
+// expiration, negative values and timestamps in the past mean the item is
+// already expired.
+func expiration(exptime int64, now time.Time) (ttl time.Duration, expired bool) {
+	switch {
+	case exptime == 0:
+		return 0, false
+	case exptime < 0:
+		return 0, true
+	case exptime <= maxRelativeExpiration:
+		return time.Duration(exptime) * time.Second, false
+	}
+
+	ttl = time.Unix(exptime, 0).Sub(now)
+	return ttl, ttl <= 0
+}
+
+func validKey(key []byte) bool {
+	if len(key) == 0 || len(key) > MaxKeyLength {
+		return false
+	}
+	for _, b := range key {
+		if b <= ' ' || b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
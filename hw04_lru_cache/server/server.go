@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	hw04lrucache "github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache"
+)
+
+const (
+	DefaultMaxItemSize = 1 << 20
+
+	maxLineLength = 16 << 10
+
+	shutdownPollInterval = 10 * time.Millisecond
+)
+
+var ErrServerClosed = errors.New("cache server closed")
+
+// Item is what the server keeps in the cache for every key.
+type Item struct {
+	Flags uint32
+	Data  []byte
+}
+
+// Cost is the cost of an item in the cache: the size of its key and data.
+func Cost(key string, item Item) int64 {
+	return int64(len(key) + len(item.Data))
+}
+
+// Server exposes a cache over a subset of the memcached text protocol:
+// get, set, delete, stats and quit.
+type Server struct {
+	cache       hw04lrucache.GenericCache[string, Item]
+	maxItemSize int
+	started     time.Time
+
+	mu         sync.Mutex
+	listeners  map[net.Listener]struct{}
+	conns      map[*conn]struct{}
+	inShutdown atomic.Bool
+
+	totalConns atomic.Uint64
+	cmdGet     atomic.Uint64
+	cmdSet     atomic.Uint64
+}
+
+// New serves cache. Items are stored with the cost given by Cost, so a cache
+// created with WithMaxCost is bounded by the size of the stored data.
+func New(cache hw04lrucache.GenericCache[string, Item], maxItemSize int) *Server {
+	if maxItemSize <= 0 {
+		maxItemSize = DefaultMaxItemSize
+	}
+	return &Server{
+		cache:       cache,
+		maxItemSize: maxItemSize,
+		started:     time.Now(),
+		listeners:   make(map[net.Listener]struct{}),
+		conns:       make(map[*conn]struct{}),
+	}
+}
+
+func (s *Server) ListenAndServe(address string) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and handles each in its own goroutine.
+// It always returns a non-nil error; after Shutdown it is ErrServerClosed.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.trackListener(l) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrackListener(l)
+
+	for {
+		netConn, err := l.Accept()
+		if err != nil {
+			if s.inShutdown.Load() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		c := &conn{server: s, netConn: netConn}
+		if !s.trackConn(c) {
+			netConn.Close()
+			return ErrServerClosed
+		}
+		go c.serve()
+	}
+}
+
+// Shutdown stops accepting connections, closes idle ones and waits for the
+// commands in progress to finish. When ctx ends first, the remaining
+// connections are closed and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.inShutdown.Store(true)
+
+	s.mu.Lock()
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		if s.closeIdleConns() {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			s.closeAllConns()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) trackListener(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inShutdown.Load() {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackListener(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, l)
+}
+
+func (s *Server) trackConn(c *conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inShutdown.Load() {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	s.totalConns.Add(1)
+	return true
+}
+
+func (s *Server) untrackConn(c *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, c)
+}
+
+func (s *Server) currConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+// closeIdleConns closes connections waiting for a command and reports
+// whether no connections are left.
+func (s *Server) closeIdleConns() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		if c.idle.Load() {
+			c.netConn.Close()
+		}
+	}
+	return len(s.conns) == 0
+}
+
+func (s *Server) closeAllConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		c.netConn.Close()
+	}
+}
+
+type conn struct {
+	server  *Server
+	netConn net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	idle    atomic.Bool
+}
+
+func (c *conn) serve() {
+	defer c.server.untrackConn(c)
+	defer c.netConn.Close()
+
+	c.r = bufio.NewReaderSize(c.netConn, maxLineLength)
+	c.w = bufio.NewWriter(c.netConn)
+	// Send the replies to commands that already ran, also when Shutdown stops
+	// the loop with pipelined input left.
+	defer c.w.Flush()
+
+	for !c.server.inShutdown.Load() {
+		c.idle.Store(true)
+		line, err := c.readLine()
+		c.idle.Store(false)
+		if err != nil {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				c.w.WriteString("CLIENT_ERROR line too long\r\n")
+			}
+			return
+		}
+
+		if !c.handle(line) {
+			return
+		}
+		// Flush once the pipelined commands already received are answered.
+		if c.r.Buffered() == 0 {
+			if err := c.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLine returns the next line without its "\r\n" or "\n" terminator.
+func (c *conn) readLine() ([]byte, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line, nil
+}
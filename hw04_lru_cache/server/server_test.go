@@ -0,0 +1,382 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	hw04lrucache "github.com/NikitaZheleznov/otus_homwork_go/hw04_lru_cache"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, capacity, maxItemSize int) (*Server, string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+
+	srv := New(hw04lrucache.NewGenericCache[string, Item](capacity), maxItemSize)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(l)
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, srv.Shutdown(ctx))
+		require.ErrorIs(t, <-errs, ErrServerClosed)
+	})
+	return srv, l.Addr().String()
+}
+
+type session struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dial(t *testing.T, address string) *session {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	return &session{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (s *session) send(request string) {
+	s.t.Helper()
+
+	_, err := io.WriteString(s.conn, request)
+	require.NoError(s.t, err)
+}
+
+// expect reads as many lines as response has and compares them.
+func (s *session) expect(response string) {
+	s.t.Helper()
+
+	var got strings.Builder
+	for range strings.Count(response, "\n") {
+		line, err := s.r.ReadString('\n')
+		require.NoError(s.t, err)
+		got.WriteString(line)
+	}
+	require.Equal(s.t, response, got.String())
+}
+
+func TestServer(t *testing.T) {
+	t.Run("set get delete", func(t *testing.T) {
+		_, address := startServer(t, 10, 0)
+		s := dial(t, address)
+
+		s.send("get a\r\n")
+		s.expect("END\r\n")
+
+		s.send("set a 5 0 5\r\nhello\r\n")
+		s.expect("STORED\r\n")
+		s.send("set b 0 0 0\r\n\r\n")
+		s.expect("STORED\r\n")
+
+		s.send("get a b c\r\n")
+		s.expect("VALUE a 5 5\r\nhello\r\nVALUE b 0 0\r\n\r\nEND\r\n")
+
+		s.send("delete a\r\n")
+		s.expect("DELETED\r\n")
+		s.send("delete a\r\n")
+		s.expect("NOT_FOUND\r\n")
+		s.send("get a\r\n")
+		s.expect("END\r\n")
+	})
+
+	t.Run("binary data and bare newlines", func(t *testing.T) {
+		_, address := startServer(t, 10, 0)
+		s := dial(t, address)
+
+		s.send("set k 0 0 4\nab\r\n\r\n")
+		s.expect("STORED\r\n")
+		s.send("get k\n")
+		s.expect("VALUE k 0 4\r\nab\r\n\r\nEND\r\n")
+	})
+
+	t.Run("noreply and pipelining", func(t *testing.T) {
+		_, address := startServer(t, 10, 0)
+		s := dial(t, address)
+
+		s.send("set a 0 0 1 noreply\r\n1\r\nset b 0 0 1 noreply\r\n2\r\ndelete a noreply\r\nget a b\r\n")
+		s.expect("VALUE b 0 1\r\n2\r\nEND\r\n")
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		_, address := startServer(t, 10, 0)
+		s := dial(t, address)
+
+		s.send("set a 0 0 1\r\n1\r\n")
+		s.expect("STORED\r\n")
+		s.send("set a 0 -1 1\r\n1\r\n")
+		s.expect("STORED\r\n")
+		s.send("set b 0 1000 1\r\n2\r\n")
+		s.expect("STORED\r\n")
+		s.send("get a b\r\n")
+		s.expect("VALUE b 0 1\r\n2\r\nEND\r\n")
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		_, address := startServer(t, 10, 0)
+		s := dial(t, address)
+
+		s.send("quit\r\n")
+		_, err := s.r.ReadByte()
+		require.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func TestServerErrors(t *testing.T) {
+	t.Run("errors", func(t *testing.T) {
+		_, address := startServer(t, 10, 4)
+		s := dial(t, address)
+
+		s.send("\r\n")
+		s.expect("ERROR\r\n")
+		s.send("incr a 1\r\n")
+		s.expect("ERROR\r\n")
+		s.send("get\r\n")
+		s.expect("ERROR\r\n")
+		s.send("set a x 0 1\r\n1\r\n")
+		s.expect("CLIENT_ERROR bad command line format\r\n")
+		s.send("get " + strings.Repeat("k", MaxKeyLength+1) + "\r\n")
+		s.expect("CLIENT_ERROR bad command line format\r\n")
+
+		s.send("set a 0 0 1\r\n12\r\n")
+		s.expect("CLIENT_ERROR bad data chunk\r\n")
+
+		s.send("set a 0 0 5\r\nhello\r\n")
+		s.expect("SERVER_ERROR object too large for cache\r\n")
+		s.send("get a\r\n")
+		s.expect("END\r\n")
+	})
+
+	t.Run("rejected set data is never executed", func(t *testing.T) {
+		_, address := startServer(t, 10, 0)
+		s := dial(t, address)
+
+		s.send("set a 0 0 1\r\n1\r\n")
+		s.expect("STORED\r\n")
+		for _, tc := range []struct {
+			request string
+			reply   string
+		}{
+			{"set " + strings.Repeat("k", MaxKeyLength+1) + " 0 0 8", "CLIENT_ERROR bad command line format\r\n"},
+			{"set bad x 0 8", "CLIENT_ERROR bad command line format\r\n"},
+			{"set bad 0 x 8", "CLIENT_ERROR bad command line format\r\n"},
+			{"set bad 0 0 8 extra", "ERROR\r\n"},
+		} {
+			s.send(tc.request + "\r\ndelete a\r\n")
+			s.expect(tc.reply)
+		}
+		s.send("get a\r\n")
+		s.expect("VALUE a 0 1\r\n1\r\nEND\r\n")
+
+		for _, tc := range []struct {
+			request string
+			reply   string
+		}{
+			{"set bad 0 0", "ERROR\r\n"},
+			{"set bad 0 0 x", "CLIENT_ERROR bad command line format\r\n"},
+			{"set bad 0 0 -1", "CLIENT_ERROR bad command line format\r\n"},
+			{"set bad 0 0 2147483648", "CLIENT_ERROR bad command line format\r\n"},
+			{"set bad 0 0 9223372036854775807", "CLIENT_ERROR bad command line format\r\n"},
+		} {
+			s := dial(t, address)
+			s.send(tc.request + "\r\ndelete a\r\n")
+			s.expect(tc.reply)
+			_, err := s.r.ReadByte()
+			require.ErrorIs(t, err, io.EOF)
+		}
+		s.send("get a\r\n")
+		s.expect("VALUE a 0 1\r\n1\r\nEND\r\n")
+	})
+
+	t.Run("item over the cache budget", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:")
+		require.NoError(t, err)
+		srv := New(hw04lrucache.NewGenericCache[string, Item](0, hw04lrucache.WithMaxCost(10)), 0)
+		go srv.Serve(l)
+		defer srv.Shutdown(context.Background())
+
+		s := dial(t, l.Addr().String())
+		s.send("set a 0 0 9\r\n123456789\r\n")
+		s.expect("STORED\r\n")
+		s.send("set a 0 0 20\r\n12345678901234567890\r\n")
+		s.expect("SERVER_ERROR object too large for cache\r\n")
+		s.send("get a\r\n")
+		s.expect("END\r\n")
+	})
+}
+
+func TestServerStats(t *testing.T) {
+	_, address := startServer(t, 1, 0)
+	s := dial(t, address)
+
+	s.send("set a 0 0 1\r\n1\r\nset b 0 0 1\r\n2\r\nget a b\r\n")
+	s.expect("STORED\r\nSTORED\r\nVALUE b 0 1\r\n2\r\nEND\r\n")
+
+	s.send("stats\r\n")
+	stats := make(map[string]string)
+	for {
+		line, err := s.r.ReadString('\n')
+		require.NoError(t, err)
+		if line == "END\r\n" {
+			break
+		}
+		fields := strings.Fields(line)
+		require.Len(t, fields, 3)
+		require.Equal(t, "STAT", fields[0])
+		stats[fields[1]] = fields[2]
+	}
+	require.Equal(t, "1", stats["curr_items"])
+	require.Equal(t, "1", stats["get_hits"])
+	require.Equal(t, "1", stats["get_misses"])
+	require.Equal(t, "1", stats["evictions"])
+	require.Equal(t, "1", stats["curr_connections"])
+	require.Equal(t, "2", stats["cmd_get"])
+	require.Equal(t, "2", stats["cmd_set"])
+}
+
+func TestServerShutdown(t *testing.T) {
+	t.Run("closes idle connections", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:")
+		require.NoError(t, err)
+		srv := New(hw04lrucache.NewGenericCache[string, Item](10), 0)
+		errs := make(chan error, 1)
+		go func() {
+			errs <- srv.Serve(l)
+		}()
+
+		s := dial(t, l.Addr().String())
+		s.send("set a 0 0 1\r\n1\r\n")
+		s.expect("STORED\r\n")
+
+		require.NoError(t, srv.Shutdown(context.Background()))
+		require.ErrorIs(t, <-errs, ErrServerClosed)
+
+		_, err = s.r.ReadByte()
+		require.ErrorIs(t, err, io.EOF)
+		_, err = net.Dial("tcp", l.Addr().String())
+		require.Error(t, err)
+		require.ErrorIs(t, srv.Serve(l), ErrServerClosed)
+	})
+
+	t.Run("waits for commands in progress", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:")
+		require.NoError(t, err)
+		srv := New(hw04lrucache.NewGenericCache[string, Item](10), 0)
+		go srv.Serve(l)
+
+		s := dial(t, l.Addr().String())
+		s.send("set a 0 0 5\r\nhel")
+		require.Eventually(t, func() bool {
+			return srv.currConns() == 1 && !firstConn(srv).idle.Load()
+		}, time.Second, time.Millisecond)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- srv.Shutdown(context.Background())
+		}()
+		select {
+		case <-done:
+			require.Fail(t, "shutdown returned before the command finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		s.send("lo\r\n")
+		s.expect("STORED\r\n")
+		require.NoError(t, <-done)
+		_, err = s.r.ReadByte()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("flushes replies to pipelined commands", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:")
+		require.NoError(t, err)
+		srv := New(hw04lrucache.NewGenericCache[string, Item](10), 0)
+		go srv.Serve(l)
+
+		s := dial(t, l.Addr().String())
+		s.send("set a 0 0 5\r\nhel")
+		require.Eventually(t, func() bool {
+			return srv.currConns() == 1 && !firstConn(srv).idle.Load()
+		}, time.Second, time.Millisecond)
+
+		done := make(chan error, 1)
+		go func() {
+			done <- srv.Shutdown(context.Background())
+		}()
+		require.Eventually(t, srv.inShutdown.Load, time.Second, time.Millisecond)
+
+		// The get is buffered when the set finishes, so the loop stops
+		// without answering it.
+		s.send("lo\r\nget a\r\n")
+		s.expect("STORED\r\n")
+		require.NoError(t, <-done)
+		_, err = s.r.ReadByte()
+		require.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("closes everything when the context ends", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:")
+		require.NoError(t, err)
+		srv := New(hw04lrucache.NewGenericCache[string, Item](10), 0)
+		go srv.Serve(l)
+
+		s := dial(t, l.Addr().String())
+		s.send("set a 0 0 5\r\nhel")
+		require.Eventually(t, func() bool {
+			return srv.currConns() == 1 && !firstConn(srv).idle.Load()
+		}, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, srv.Shutdown(ctx), context.DeadlineExceeded)
+
+		_, err = s.r.ReadByte()
+		require.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func firstConn(srv *Server) *conn {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for c := range srv.conns {
+		return c
+	}
+	return nil
+}
+
+func TestExpiration(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	for _, tc := range []struct {
+		exptime int64
+		ttl     time.Duration
+		expired bool
+	}{
+		{exptime: 0},
+		{exptime: -1, expired: true},
+		{exptime: 60, ttl: time.Minute},
+		{exptime: maxRelativeExpiration, ttl: maxRelativeExpiration * time.Second},
+		{exptime: now.Unix() + 10, ttl: 10 * time.Second},
+		{exptime: now.Unix(), expired: true},
+		{exptime: maxRelativeExpiration + 1, ttl: time.Unix(maxRelativeExpiration+1, 0).Sub(now), expired: true},
+	} {
+		ttl, expired := expiration(tc.exptime, now)
+		require.Equal(t, tc.expired, expired, tc.exptime)
+		if !tc.expired {
+			require.Equal(t, tc.ttl, ttl, tc.exptime)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package hw04lrucache
+
+import (
+	"context"
+	"hash/maphash"
+	"io"
+	"iter"
+	"runtime"
+	"time"
+)
+
+type shardedCache[K comparable, V any] struct {
+	shards []*cache[K, V]
+	hash   func(K) uint64
+}
+
+// NewShardedCache splits capacity across independent LRU segments so that
+// operations on keys from different shards do not contend for one mutex.
+// A non-positive shards value uses GOMAXPROCS segments.
+// With WithMaxCost every segment gets an equal share of the budget, so an entry
+// costing more than one share is rejected with ErrTooLarge; the shard count is
+// lowered so that every share is at least 1.
+func NewShardedCache(capacity, shards int, opts ...Option) Cache {
+	seed := maphash.MakeSeed()
+	return NewGenericShardedCache[Key, interface{}](capacity, shards, func(key Key) uint64 {
+		return maphash.String(seed, string(key))
+	}, opts...)
+}
+
+func NewGenericShardedCache[K comparable, V any](
+	capacity, shards int, hash func(K) uint64, opts ...Option,
+) GenericCache[K, V] {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	if capacity > 0 && shards > capacity {
+		shards = capacity
+	}
+
+	var budgets []int64
+	if o := newOptions(opts); o.maxCost > 0 {
+		if int64(shards) > o.maxCost {
+			shards = int(o.maxCost)
+		}
+		budgets = split(o.maxCost, shards)
+	}
+
+	s := &shardedCache[K, V]{
+		shards: make([]*cache[K, V], shards),
+		hash:   hash,
+	}
+	for i, capacity := range split(capacity, shards) {
+		shardOpts := opts
+		if budgets != nil {
+			shardOpts = append(opts[:len(opts):len(opts)], WithMaxCost(budgets[i]))
+		}
+		s.shards[i] = newCache[K, V](capacity, newLRUPolicy[K, V](), shardOpts)
+	}
+	return s
+}
+
+// split divides total into n parts that differ by at most one.
+func split[T int | int64](total T, n int) []T {
+	parts := make([]T, n)
+	for i := range parts {
+		parts[i] = total / T(n)
+		if T(i) < total%T(n) {
+			parts[i]++
+		}
+	}
+	return parts
+}
+
+func (s *shardedCache[K, V]) shard(key K) *cache[K, V] {
+	return s.shards[s.hash(key)%uint64(len(s.shards))]
+}
+
+func (s *shardedCache[K, V]) Set(key K, value V) bool {
+	return s.shard(key).Set(key, value)
+}
+
+func (s *shardedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	return s.shard(key).SetWithTTL(key, value, ttl)
+}
+
+func (s *shardedCache[K, V]) SetWithCost(key K, value V, cost int64) (bool, error) {
+	return s.shard(key).SetWithCost(key, value, cost)
+}
+
+func (s *shardedCache[K, V]) SetWithTTLAndCost(key K, value V, ttl time.Duration, cost int64) (bool, error) {
+	return s.shard(key).SetWithTTLAndCost(key, value, ttl, cost)
+}
+
+func (s *shardedCache[K, V]) Get(key K) (V, bool) {
+	return s.shard(key).Get(key)
+}
+
+func (s *shardedCache[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	return s.shard(key).GetOrLoad(ctx, key, loader)
+}
+
+func (s *shardedCache[K, V]) Peek(key K) (V, bool) {
+	return s.shard(key).Peek(key)
+}
+
+func (s *shardedCache[K, V]) Contains(key K) bool {
+	return s.shard(key).Contains(key)
+}
+
+func (s *shardedCache[K, V]) Delete(key K) bool {
+	return s.shard(key).Delete(key)
+}
+
+// Save writes the entries of all shards as one snapshot, shard by shard.
+func (s *shardedCache[K, V]) Save(w io.Writer) error {
+	var entries []snapshotEntry[K, V]
+	for _, shard := range s.shards {
+		entries = append(entries, shard.entries()...)
+	}
+	return writeSnapshot(w, s.shards[0].codec, entries)
+}
+
+// Load distributes the snapshot entries over the current shards, so a snapshot
+// can be loaded into a cache with a different shard count.
+func (s *shardedCache[K, V]) Load(r io.Reader) error {
+	entries, err := readSnapshot[K, V](r, s.shards[0].codec)
+	if err != nil {
+		return err
+	}
+
+	perShard := make([][]snapshotEntry[K, V], len(s.shards))
+	for _, e := range entries {
+		i := s.hash(e.Key) % uint64(len(s.shards))
+		perShard[i] = append(perShard[i], e)
+	}
+	for i, shard := range s.shards {
+		shard.restore(perShard[i])
+	}
+	return nil
+}
+
+func (s *shardedCache[K, V]) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Keys returns keys shard by shard; recency order holds only within a shard.
+func (s *shardedCache[K, V]) Keys() []K {
+	var keys []K
+	for _, shard := range s.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// All iterates shard by shard; recency order holds only within a shard.
+func (s *shardedCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, shard := range s.shards {
+			for k, v := range shard.All() {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Resize keeps at least one entry per shard: a positive capacity below the
+// shard count is raised to it, as the constructor lowers the shard count.
+func (s *shardedCache[K, V]) Resize(capacity int) int {
+	if capacity > 0 {
+		capacity = max(capacity, len(s.shards))
+	}
+	evicted := 0
+	for i, capacity := range split(capacity, len(s.shards)) {
+		evicted += s.shards[i].Resize(capacity)
+	}
+	return evicted
+}
+
+func (s *shardedCache[K, V]) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+func (s *shardedCache[K, V]) Close() {
+	for _, shard := range s.shards {
+		shard.Close()
+	}
+}
+
+func (s *shardedCache[K, V]) Stats() Stats {
+	var hits, misses, evictions uint64
+	size := 0
+	for _, shard := range s.shards {
+		st := shard.Stats()
+		hits += st.Hits
+		misses += st.Misses
+		evictions += st.Evictions
+		size += st.Size
+	}
+	return newStats(hits, misses, evictions, size)
+}
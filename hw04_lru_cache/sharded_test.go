@@ -0,0 +1,127 @@
+package hw04lrucache
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCache(t *testing.T) {
+	t.Run("simple", func(t *testing.T) {
+		c := NewShardedCache(8, 4)
+
+		wasInCache := c.Set("aaa", 100)
+		require.False(t, wasInCache)
+		wasInCache = c.Set("aaa", 200)
+		require.True(t, wasInCache)
+
+		val, ok := c.Get("aaa")
+		require.True(t, ok)
+		require.Equal(t, 200, val)
+
+		require.True(t, c.Delete("aaa"))
+		_, ok = c.Get("aaa")
+		require.False(t, ok)
+	})
+
+	t.Run("capacity is split across shards", func(t *testing.T) {
+		require.Equal(t, []int{3, 3, 2, 2}, split(10, 4))
+
+		// Shard i holds keys with k%4 == i and has capacity 3, 3, 2 and 2.
+		c := NewGenericShardedCache[int, int](10, 4, func(k int) uint64 { return uint64(k) })
+		for i := range 100 {
+			c.Set(i, i)
+		}
+		require.Equal(t, 10, c.Len())
+
+		keys := c.Keys()
+		sort.Ints(keys)
+		require.Equal(t, []int{88, 89, 92, 93, 94, 95, 96, 97, 98, 99}, keys)
+	})
+
+	t.Run("shard count", func(t *testing.T) {
+		c := NewShardedCache(2, 16).(*shardedCache[Key, interface{}])
+		require.Len(t, c.shards, 2)
+
+		c = NewShardedCache(100, 0).(*shardedCache[Key, interface{}])
+		require.NotEmpty(t, c.shards)
+	})
+
+	t.Run("resize keeps every shard usable", func(t *testing.T) {
+		c := NewGenericShardedCache[int, int](20, 4, func(k int) uint64 { return uint64(k) })
+		for i := range 20 {
+			c.Set(i, i)
+		}
+
+		require.Equal(t, 16, c.Resize(1))
+		require.Equal(t, 4, c.Len())
+		for i := range 20 {
+			c.Set(i, i)
+			require.True(t, c.Contains(i), i)
+		}
+	})
+
+	t.Run("budget is split across shards", func(t *testing.T) {
+		c := NewShardedCache(0, 4, WithMaxCost(10))
+		_, err := c.SetWithCost("a", 1, 2)
+		require.NoError(t, err)
+		_, err = c.SetWithCost("b", 2, 5)
+		require.ErrorIs(t, err, ErrTooLarge)
+
+		c = NewShardedCache(0, 4, WithMaxCost(2))
+		require.Len(t, c.(*shardedCache[Key, interface{}]).shards, 2)
+		for i := range 10 {
+			_, err := c.SetWithCost(Key(strconv.Itoa(i)), i, 1)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, c.Len())
+	})
+
+	t.Run("aggregates", func(t *testing.T) {
+		c := NewShardedCache(100, 4)
+		for i := range 10 {
+			c.Set(Key(strconv.Itoa(i)), i)
+		}
+		c.Get("1")
+		c.Get("missing")
+
+		require.Equal(t, Stats{Hits: 1, Misses: 1, Size: 10, HitRatio: 0.5}, c.Stats())
+
+		n := 0
+		for range c.All() {
+			n++
+		}
+		require.Equal(t, 10, n)
+
+		evicted := c.Resize(4)
+		require.LessOrEqual(t, c.Len(), 4)
+		require.Equal(t, 10, evicted+c.Len())
+
+		c.Clear()
+		require.Equal(t, 0, c.Len())
+		c.Close()
+	})
+}
+
+func TestShardedCacheMultithreading(t *testing.T) {
+	c := NewShardedCache(100, 8)
+	wg := &sync.WaitGroup{}
+
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 10_000 {
+				key := Key(strconv.Itoa(g*10_000 + i))
+				c.Set(key, i)
+				c.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.LessOrEqual(t, c.Len(), 100)
+}
@@ -0,0 +1,167 @@
+package hw04lrucache
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	snapshotVersion = 1
+
+	// maxSnapshotPrealloc bounds the entries allocated upfront from an
+	// untrusted header count.
+	maxSnapshotPrealloc = 1024
+)
+
+var (
+	ErrSnapshotVersion   = errors.New("unsupported cache snapshot version")
+	ErrSnapshotMalformed = errors.New("malformed cache snapshot")
+)
+
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// Codec turns snapshot records into a byte stream and back.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+var (
+	// GobCodec keeps Go types intact. Concrete types stored in interface
+	// values must be registered with gob.Register.
+	GobCodec Codec = gobCodec{}
+	// JSONCodec writes human-readable snapshots. Values decoded into an
+	// interface{} get JSON types, e.g. numbers become float64.
+	JSONCodec Codec = jsonCodec{}
+)
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder { return gob.NewEncoder(w) }
+
+func (gobCodec) NewDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+type snapshotHeader struct {
+	Version int
+	Count   int
+}
+
+type snapshotEntry[K comparable, V any] struct {
+	Key       K
+	Value     V
+	ExpiresAt int64         `json:",omitempty"`
+	TTL       time.Duration `json:",omitempty"`
+	Cost      int64
+}
+
+// Save writes the live entries from the most to the least recently used,
+// with their absolute expiration times and costs.
+func (c *cache[K, V]) Save(w io.Writer) error {
+	return writeSnapshot(w, c.codec, c.entries())
+}
+
+// Load adds the entries of a snapshot written by Save on top of the current
+// contents, restoring their recency order. Entries that expired in the
+// meantime are skipped. Policies other than LRU only get the order back, not
+// their frequency statistics.
+func (c *cache[K, V]) Load(r io.Reader) error {
+	entries, err := readSnapshot[K, V](r, c.codec)
+	if err != nil {
+		return err
+	}
+	c.restore(entries)
+	return nil
+}
+
+func (c *cache[K, V]) entries() []snapshotEntry[K, V] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	items := c.snapshot()
+	entries := make([]snapshotEntry[K, V], len(items))
+	for i, item := range items {
+		entries[i] = snapshotEntry[K, V]{
+			Key:       item.key,
+			Value:     item.value,
+			ExpiresAt: item.expiresAt,
+			TTL:       item.ttl,
+			Cost:      item.cost,
+		}
+	}
+	return entries
+}
+
+// restore inserts entries from the least recently used one, so that the first
+// entry ends up at the front.
+func (c *cache[K, V]) restore(entries []snapshotEntry[K, V]) {
+	c.mutex.Lock()
+	defer c.unlock()
+
+	now := c.now().UnixNano()
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.ExpiresAt != 0 && now >= e.ExpiresAt {
+			continue
+		}
+		ttl := e.TTL
+		if ttl == 0 && e.ExpiresAt != 0 {
+			// Snapshots without TTLs keep the time the entry had left.
+			ttl = time.Duration(e.ExpiresAt - now)
+		}
+		c.store(e.Key, e.Value, ttl, e.ExpiresAt, e.Cost)
+	}
+}
+
+func writeSnapshot[K comparable, V any](w io.Writer, codec Codec, entries []snapshotEntry[K, V]) error {
+	enc := codec.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Count: len(entries)}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readSnapshot[K comparable, V any](r io.Reader, codec Codec) ([]snapshotEntry[K, V], error) {
+	dec := codec.NewDecoder(r)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, err
+	}
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, header.Version)
+	}
+
+	if header.Count < 0 {
+		return nil, fmt.Errorf("%w: negative entry count %d", ErrSnapshotMalformed, header.Count)
+	}
+
+	entries := make([]snapshotEntry[K, V], 0, min(header.Count, maxSnapshotPrealloc))
+	for range header.Count {
+		var e snapshotEntry[K, V]
+		if err := dec.Decode(&e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
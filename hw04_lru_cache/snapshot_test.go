@@ -0,0 +1,121 @@
+package hw04lrucache
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Run("gob keeps order and values", func(t *testing.T) {
+		src := NewCache(5)
+		src.Set("a", 1)
+		src.Set("b", "two")
+		src.Set("c", 3.5)
+		src.Get("a") // [a, c, b]
+
+		var buf bytes.Buffer
+		require.NoError(t, src.Save(&buf))
+
+		dst := NewCache(5)
+		require.NoError(t, dst.Load(&buf))
+		require.Equal(t, []Key{"a", "c", "b"}, dst.Keys())
+
+		val, ok := dst.Get("b")
+		require.True(t, ok)
+		require.Equal(t, "two", val)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		src := NewGenericCache[string, int](5, WithCodec(JSONCodec))
+		src.Set("a", 1)
+		src.Set("b", 2)
+
+		var buf bytes.Buffer
+		require.NoError(t, src.Save(&buf))
+		require.Contains(t, buf.String(), `"Key":"b"`)
+
+		dst := NewGenericCache[string, int](5, WithCodec(JSONCodec))
+		require.NoError(t, dst.Load(&buf))
+		require.Equal(t, []string{"b", "a"}, dst.Keys())
+	})
+
+	t.Run("ttl and cost are preserved", func(t *testing.T) {
+		clock := newFakeClock()
+		src := NewCache(5, WithMaxCost(10), withClock(clock.Now))
+		src.SetWithTTL("short", 1, time.Second)
+		src.SetWithTTL("long", 2, time.Hour)
+		src.SetWithCost("heavy", 3, 8)
+
+		var buf bytes.Buffer
+		require.NoError(t, src.Save(&buf))
+
+		clock.Add(time.Second)
+		dst := NewCache(5, WithMaxCost(10), withClock(clock.Now))
+		require.NoError(t, dst.Load(&buf))
+		require.Equal(t, []Key{"heavy", "long"}, dst.Keys())
+		require.Equal(t, time.Hour, dst.(*cache[Key, interface{}]).items["long"].ttl)
+
+		clock.Add(time.Hour)
+		require.False(t, dst.Contains("long"))
+
+		_, err := dst.SetWithCost("light", 4, 3) // 8 + 3 > 10
+		require.NoError(t, err)
+		require.False(t, dst.Contains("heavy"))
+	})
+
+	t.Run("load into a smaller cache keeps the most recent", func(t *testing.T) {
+		src := NewCache(10)
+		for i := range 10 {
+			src.Set(Key(strconv.Itoa(i)), i)
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, src.Save(&buf))
+
+		dst := NewLFUCache(3)
+		require.NoError(t, dst.Load(&buf))
+		require.ElementsMatch(t, []Key{"9", "8", "7"}, dst.Keys())
+	})
+
+	t.Run("sharded", func(t *testing.T) {
+		src := NewShardedCache(40, 4)
+		for i := range 10 {
+			src.Set(Key(strconv.Itoa(i)), i)
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, src.Save(&buf))
+
+		dst := NewShardedCache(30, 3)
+		require.NoError(t, dst.Load(&buf))
+		require.ElementsMatch(t, src.Keys(), dst.Keys())
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		c := NewCache(1, WithCodec(JSONCodec))
+		err := c.Load(strings.NewReader(`{"Version":2,"Count":0}`))
+		require.ErrorIs(t, err, ErrSnapshotVersion)
+	})
+
+	t.Run("bad entry count", func(t *testing.T) {
+		c := NewCache(5, WithCodec(JSONCodec))
+		err := c.Load(strings.NewReader(`{"Version":1,"Count":-1}`))
+		require.ErrorIs(t, err, ErrSnapshotMalformed)
+
+		err = c.Load(strings.NewReader(`{"Version":1,"Count":9223372036854775807}`))
+		require.Error(t, err)
+		require.Equal(t, 0, c.Len())
+	})
+
+	t.Run("truncated snapshot", func(t *testing.T) {
+		c := NewCache(5, WithCodec(JSONCodec))
+		err := c.Load(strings.NewReader(`{"Version":1,"Count":2}` + "\n" + `{"Key":"a","Value":1,"Cost":1}`))
+		require.Error(t, err)
+		require.Equal(t, 0, c.Len())
+	})
+}
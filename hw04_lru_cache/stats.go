@@ -0,0 +1,48 @@
+package hw04lrucache
+
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota
+	EvictExpired
+	EvictDeleted
+	EvictCleared
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictDeleted:
+		return "deleted"
+	case EvictCleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+// Stats is a point-in-time snapshot of cache counters.
+// Evictions counts entries removed because of capacity or expiration.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+	HitRatio  float64
+}
+
+func newStats(hits, misses, evictions uint64, size int) Stats {
+	s := Stats{
+		Hits:      hits,
+		Misses:    misses,
+		Evictions: evictions,
+		Size:      size,
+	}
+	if total := hits + misses; total > 0 {
+		s.HitRatio = float64(hits) / float64(total)
+	}
+	return s
+}
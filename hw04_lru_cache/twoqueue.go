@@ -0,0 +1,160 @@
+package hw04lrucache
+
+const (
+	queueA1in uint8 = iota
+	queueAm
+)
+
+// twoQueuePolicy implements the full 2Q algorithm (Johnson and Shasha, 1994).
+// New items enter the FIFO a1in; items evicted from it leave their key in the
+// ghost queue a1out, and only keys seen again while remembered there get into
+// the LRU am. One-off scans therefore never push out the frequently used set.
+type twoQueuePolicy[K comparable, V any] struct {
+	kin   int
+	kout  int
+	a1in  GenericList[*cacheItem[K, V]]
+	am    GenericList[*cacheItem[K, V]]
+	a1out ghostQueue[K]
+	hot   bool
+}
+
+func New2QCache(capacity int, opts ...Option) Cache {
+	return NewGeneric2QCache[Key, interface{}](capacity, opts...)
+}
+
+func NewGeneric2QCache[K comparable, V any](capacity int, opts ...Option) GenericCache[K, V] {
+	return newCache[K, V](capacity, newTwoQueuePolicy[K, V](), opts)
+}
+
+func newTwoQueuePolicy[K comparable, V any]() *twoQueuePolicy[K, V] {
+	return &twoQueuePolicy[K, V]{
+		a1in:  NewGenericList[*cacheItem[K, V]](),
+		am:    NewGenericList[*cacheItem[K, V]](),
+		a1out: newGhostQueue[K](),
+	}
+}
+
+func (p *twoQueuePolicy[K, V]) admit(key K) {
+	p.hot = p.a1out.remove(key)
+}
+
+func (p *twoQueuePolicy[K, V]) add(item *cacheItem[K, V]) {
+	if p.hot {
+		item.queue = queueAm
+		item.node = p.am.PushFront(item)
+	} else {
+		item.queue = queueA1in
+		item.node = p.a1in.PushFront(item)
+	}
+	p.hot = false
+}
+
+func (p *twoQueuePolicy[K, V]) access(item *cacheItem[K, V]) {
+	// Hits in a1in are treated as correlated references and do not promote.
+	if item.queue == queueAm {
+		p.am.MoveToFront(item.node)
+	}
+}
+
+func (p *twoQueuePolicy[K, V]) remove(item *cacheItem[K, V]) {
+	p.list(item.queue).Remove(item.node)
+}
+
+func (p *twoQueuePolicy[K, V]) evict() *cacheItem[K, V] {
+	if p.a1in.Len() > p.kin || p.am.Len() == 0 {
+		item := p.a1in.Back().Value
+		p.a1in.Remove(item.node)
+		p.a1out.push(item.key)
+		p.a1out.trim(p.kout)
+		return item
+	}
+	item := p.am.Back().Value
+	p.am.Remove(item.node)
+	return item
+}
+
+func (p *twoQueuePolicy[K, V]) first() *cacheItem[K, V] {
+	if item := value(p.a1in.Back()); item != nil {
+		return item
+	}
+	return value(p.am.Back())
+}
+
+func (p *twoQueuePolicy[K, V]) next(item *cacheItem[K, V]) *cacheItem[K, V] {
+	if item.node.Prev != nil {
+		return item.node.Prev.Value
+	}
+	if item.queue == queueA1in {
+		return value(p.am.Back())
+	}
+	return nil
+}
+
+func (p *twoQueuePolicy[K, V]) len() int {
+	return p.a1in.Len() + p.am.Len()
+}
+
+// resize applies the sizes recommended by the paper:
+// a quarter of the capacity for a1in and half of it for a1out.
+func (p *twoQueuePolicy[K, V]) resize(capacity int) {
+	p.kin = max(capacity/4, 1)
+	p.kout = max(capacity/2, 1)
+	p.a1out.trim(p.kout)
+}
+
+func (p *twoQueuePolicy[K, V]) clear() {
+	p.a1in = NewGenericList[*cacheItem[K, V]]()
+	p.am = NewGenericList[*cacheItem[K, V]]()
+	p.a1out = newGhostQueue[K]()
+	p.hot = false
+}
+
+func (p *twoQueuePolicy[K, V]) list(queue uint8) GenericList[*cacheItem[K, V]] {
+	if queue == queueAm {
+		return p.am
+	}
+	return p.a1in
+}
+
+// ghostQueue remembers the keys of recently evicted items, newest first.
+type ghostQueue[K comparable] struct {
+	keys  GenericList[K]
+	nodes map[K]*GenericListItem[K]
+}
+
+func newGhostQueue[K comparable]() ghostQueue[K] {
+	return ghostQueue[K]{
+		keys:  NewGenericList[K](),
+		nodes: make(map[K]*GenericListItem[K]),
+	}
+}
+
+func (g ghostQueue[K]) len() int {
+	return g.keys.Len()
+}
+
+func (g ghostQueue[K]) push(key K) {
+	g.nodes[key] = g.keys.PushFront(key)
+}
+
+func (g ghostQueue[K]) remove(key K) bool {
+	node, ok := g.nodes[key]
+	if ok {
+		g.keys.Remove(node)
+		delete(g.nodes, key)
+	}
+	return ok
+}
+
+// trim forgets the oldest keys until at most n remain.
+func (g ghostQueue[K]) trim(n int) {
+	for g.keys.Len() > max(n, 0) {
+		g.removeOldest()
+	}
+}
+
+func (g ghostQueue[K]) removeOldest() {
+	node := g.keys.Back()
+	g.keys.Remove(node)
+	delete(g.nodes, node.Value)
+}